@@ -7,6 +7,8 @@ import (
 	"nova-cdn/internal/config"
 	"nova-cdn/internal/middleware"
 	"nova-cdn/internal/routes"
+	"nova-cdn/internal/storage"
+	"nova-cdn/internal/worker"
 	"os"
 
 	"strings"
@@ -40,8 +42,32 @@ func main() {
 
 	config.ConnectDatabase()
 
+	if len(os.Args) > 1 && os.Args[1] == "migrate" {
+		runMigrate(os.Args[2:])
+		return
+	}
+
+	storage.ConnectStorage()
+
+	if len(os.Args) > 1 && os.Args[1] == "reconcile" {
+		runReconcile(os.Args[2:])
+		return
+	}
+
+	if len(os.Args) > 1 && os.Args[1] == "move-private" {
+		runMovePrivate(os.Args[2:])
+		return
+	}
+
+	// Private and transformation URLs would be signed with an empty key
+	// anyone can reproduce.
+	if config.SignedURLKey == "" {
+		log.Fatal("SIGNED_URL_KEY (or APP_KEY) must be set to sign private file URLs")
+	}
+
 	app := fiber.New(fiber.Config{
-		AppName: os.Getenv("APP_NAME"),
+		AppName:   os.Getenv("APP_NAME"),
+		BodyLimit: config.BodyLimit,
 		ErrorHandler: func(c *fiber.Ctx, err error) error {
 			code := fiber.StatusInternalServerError
 			if e, ok := err.(*fiber.Error); ok {
@@ -60,6 +86,9 @@ func main() {
 
 	routes.SetupRoutes(app)
 
+	worker.NewImagePool(config.GetDB()).Start()
+	worker.NewTrashPurger(config.GetDB()).Start()
+
 	if config.AppURL != "" {
 		host := config.AppURL
 		host = strings.Replace(host, "http://", "", 1)
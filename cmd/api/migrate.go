@@ -0,0 +1,80 @@
+package main
+
+import (
+	"fmt"
+	"log"
+	"nova-cdn/internal/config"
+	"nova-cdn/internal/migrations"
+	"os"
+	"strconv"
+	"text/tabwriter"
+)
+
+// runMigrate handles `migrate up`, `migrate down [steps]` and `migrate status`.
+func runMigrate(args []string) {
+	migrator, err := migrations.New(config.GetDB())
+	if err != nil {
+		log.Fatal("Failed to load migrations: ", err)
+	}
+
+	command := "status"
+	if len(args) > 0 {
+		command = args[0]
+	}
+
+	switch command {
+	case "up":
+		ran, err := migrator.Up()
+		printMigrations("Migrated", ran)
+		if err != nil {
+			log.Fatal(err)
+		}
+		if len(ran) == 0 {
+			fmt.Println("Nothing to migrate.")
+		}
+
+	case "down":
+		steps := 1
+		if len(args) > 1 {
+			steps, err = strconv.Atoi(args[1])
+			if err != nil || steps < 1 {
+				log.Fatal("Invalid number of steps: ", args[1])
+			}
+		}
+
+		ran, err := migrator.Down(steps)
+		printMigrations("Rolled back", ran)
+		if err != nil {
+			log.Fatal(err)
+		}
+		if len(ran) == 0 {
+			fmt.Println("Nothing to roll back.")
+		}
+
+	case "status":
+		statuses, err := migrator.Status()
+		if err != nil {
+			log.Fatal(err)
+		}
+
+		w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+		fmt.Fprintln(w, "VERSION\tNAME\tAPPLIED AT")
+		for _, status := range statuses {
+			appliedAt := "pending"
+			if status.AppliedAt != nil {
+				appliedAt = status.AppliedAt.Format("2006-01-02 15:04:05")
+			}
+			fmt.Fprintf(w, "%06d\t%s\t%s\n", status.Version, status.Name, appliedAt)
+		}
+		w.Flush()
+
+	default:
+		log.Fatalf("Unknown migrate command %q, expected up, down [steps] or status", command)
+	}
+}
+
+func printMigrations(action string, ran []migrations.Migration) {
+	for _, migration := range ran {
+		fmt.Printf("%s: %06d_%s\n", action, migration.Version, migration.Name)
+	}
+}
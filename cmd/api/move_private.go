@@ -0,0 +1,55 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"log"
+	"nova-cdn/internal/config"
+	"nova-cdn/internal/reconcile"
+	"nova-cdn/internal/repositories"
+	"nova-cdn/internal/storage"
+	"os"
+	"text/tabwriter"
+)
+
+// runMovePrivate moves the files of private galleries uploaded before the
+// private disk existed from the public disk to the private one. It only lists
+// them unless --apply is given.
+func runMovePrivate(args []string) {
+	flags := flag.NewFlagSet("move-private", flag.ExitOnError)
+	apply := flags.Bool("apply", false, "move the files instead of a dry run")
+	flags.Parse(args)
+
+	reconciler := &reconcile.Reconciler{
+		GalleryRepo: repositories.NewGalleryRepository(config.GetDB()),
+		Disks:       storage.GetStorage(),
+	}
+
+	files, err := reconciler.FindMisplacedPrivate()
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	fmt.Printf("PRIVATE FILES ON THE PUBLIC DISK (%d)\n", len(files))
+	if len(files) > 0 {
+		w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+		fmt.Fprintln(w, "KEY\tSIZE")
+		for _, file := range files {
+			fmt.Fprintf(w, "%s\t%d\n", file.Key, file.Size)
+		}
+		w.Flush()
+	}
+
+	if !*apply {
+		if len(files) > 0 {
+			log.Println("Dry run, pass --apply to move them to the private disk.")
+		}
+		return
+	}
+
+	moved, err := reconciler.MovePrivateFiles(files)
+	log.Printf("Moved %d of %d files to the private disk\n", moved, len(files))
+	if err != nil {
+		log.Fatal(err)
+	}
+}
@@ -0,0 +1,95 @@
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"log"
+	"nova-cdn/internal/config"
+	"nova-cdn/internal/reconcile"
+	"nova-cdn/internal/repositories"
+	"nova-cdn/internal/storage"
+	"os"
+	"text/tabwriter"
+	"time"
+)
+
+// runReconcile compares stored files with gallery rows. It only reports
+// unless --apply is given together with --quarantine and/or --mark-broken.
+func runReconcile(args []string) {
+	flags := flag.NewFlagSet("reconcile", flag.ExitOnError)
+	asJSON := flags.Bool("json", false, "print the report as JSON")
+	quarantine := flags.Bool("quarantine", false, "move orphaned files to QUARANTINE_DIR")
+	markBroken := flags.Bool("mark-broken", false, "set broken_at on rows whose file is missing")
+	apply := flags.Bool("apply", false, "perform the selected actions instead of a dry run")
+	minAge := flags.Duration("min-age", time.Hour, "ignore files modified more recently than this")
+	flags.Parse(args)
+
+	reconciler := &reconcile.Reconciler{
+		GalleryRepo: repositories.NewGalleryRepository(config.GetDB()),
+		Disks:       storage.GetStorage(),
+		Quarantine:  storage.NewLocalStorage(config.QuarantineDir, ""),
+		MinAge:      *minAge,
+	}
+
+	report, err := reconciler.Scan()
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	if *asJSON {
+		encoder := json.NewEncoder(os.Stdout)
+		encoder.SetIndent("", "  ")
+		encoder.Encode(report)
+	} else {
+		printReport(report)
+	}
+
+	if !*apply {
+		if *quarantine || *markBroken {
+			log.Println("Dry run, pass --apply to perform the selected actions.")
+		}
+		return
+	}
+
+	if *quarantine {
+		moved, err := reconciler.QuarantineOrphans(report)
+		log.Printf("Quarantined %d of %d orphaned files to %s\n", moved, len(report.OrphanFiles), config.QuarantineDir)
+		if err != nil {
+			log.Fatal(err)
+		}
+	}
+
+	if *markBroken {
+		marked, err := reconciler.MarkBrokenRows(report)
+		if err != nil {
+			log.Fatal(err)
+		}
+		log.Printf("Marked %d broken rows\n", marked)
+	}
+}
+
+func printReport(report *reconcile.Report) {
+	fmt.Printf("Scanned %d files and %d rows.\n\n", report.ScannedFiles, report.ScannedRows)
+
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+
+	fmt.Fprintf(w, "ORPHANED FILES (%d)\n", len(report.OrphanFiles))
+	if len(report.OrphanFiles) > 0 {
+		fmt.Fprintln(w, "DISK\tKEY\tSIZE\tMODIFIED")
+		for _, orphan := range report.OrphanFiles {
+			fmt.Fprintf(w, "%s\t%s\t%d\t%s\n", orphan.Disk, orphan.Key, orphan.Size, orphan.ModTime.Format("2006-01-02 15:04:05"))
+		}
+	}
+	fmt.Fprintln(w)
+
+	fmt.Fprintf(w, "BROKEN ROWS (%d)\n", len(report.BrokenRows))
+	if len(report.BrokenRows) > 0 {
+		fmt.Fprintln(w, "ID\tDISK\tGROUP CODE\tSIZE\tFILE PATH\tTRASHED")
+		for _, row := range report.BrokenRows {
+			fmt.Fprintf(w, "%d\t%s\t%s\t%s\t%s\t%t\n", row.ID, row.Disk, row.GroupCode, row.Size, row.FilePath, row.Trashed)
+		}
+	}
+
+	w.Flush()
+}
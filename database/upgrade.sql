@@ -0,0 +1,52 @@
+-- Schema changes required by the service on top of the original tables,
+-- applied by hand before the migrate subcommand existed. New databases use
+-- `migrate up` instead.
+
+-- WebP/AVIF variants: the encoding of every stored file.
+ALTER TABLE galleries
+    ADD COLUMN format VARCHAR(10) NOT NULL DEFAULT '' AFTER size;
+
+-- Asynchronous variant processing: the persisted job queue.
+CREATE TABLE IF NOT EXISTS image_jobs (
+    id BIGINT UNSIGNED NOT NULL AUTO_INCREMENT,
+    gallery_id BIGINT UNSIGNED NOT NULL,
+    group_code VARCHAR(50) NOT NULL,
+    status VARCHAR(20) NOT NULL DEFAULT 'pending',
+    attempts INT NOT NULL DEFAULT 0,
+    max_attempts INT NOT NULL DEFAULT 5,
+    last_error TEXT NULL,
+    available_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    started_at TIMESTAMP NULL,
+    finished_at TIMESTAMP NULL,
+    created_at TIMESTAMP NULL,
+    updated_at TIMESTAMP NULL,
+    PRIMARY KEY (id),
+    KEY image_jobs_status_available_at_index (status, available_at),
+    KEY image_jobs_group_code_index (group_code)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_unicode_ci;
+
+-- Deduplicated uploads: the content hash of every stored file, and the index
+-- used to find the galleries still sharing a file before deleting it.
+ALTER TABLE galleries
+    ADD COLUMN checksum CHAR(64) NULL AFTER file_size,
+    ADD KEY galleries_checksum_index (checksum),
+    ADD KEY galleries_file_path_index (file_path);
+
+-- Owner scoped galleries: admins see every gallery.
+ALTER TABLE users ADD COLUMN role VARCHAR(20) NOT NULL DEFAULT 'user' AFTER password;
+
+-- Hand-upgraded databases: record the statements above as the migrations
+-- they correspond to before running `migrate up` for the first time.
+CREATE TABLE IF NOT EXISTS schema_migrations (
+    version BIGINT NOT NULL PRIMARY KEY,
+    name VARCHAR(255) NOT NULL,
+    applied_at TIMESTAMP NOT NULL
+);
+INSERT INTO schema_migrations (version, name, applied_at) VALUES
+    (1, 'create_users_table', NOW()),
+    (2, 'create_personal_access_tokens_table', NOW()),
+    (3, 'create_generates_table', NOW()),
+    (4, 'create_galleries_table', NOW()),
+    (5, 'add_format_and_checksum_to_galleries_table', NOW()),
+    (6, 'create_image_jobs_table', NOW()),
+    (7, 'add_role_to_users_table', NOW());
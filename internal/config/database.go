@@ -5,8 +5,10 @@ import (
 	"fmt"
 	"log"
 	"os"
+	"path/filepath"
 	"time"
 
+	"github.com/glebarez/sqlite"
 	"gorm.io/driver/mysql"
 	"gorm.io/gorm"
 	"gorm.io/gorm/logger"
@@ -34,16 +36,45 @@ func formatDuration(d time.Duration) string {
 	return fmt.Sprintf("%.1fms", float64(d.Nanoseconds())/1e6)
 }
 
+// dialector picks the driver from DB_CONNECTION. For sqlite DB_DATABASE is the
+// path of the database file, or ":memory:".
+func dialector(connection string) (gorm.Dialector, error) {
+	switch connection {
+	case "", "mysql":
+		dsn := fmt.Sprintf("%s:%s@tcp(%s:%s)/%s?charset=utf8mb4&parseTime=True&loc=Local",
+			os.Getenv("DB_USERNAME"),
+			os.Getenv("DB_PASSWORD"),
+			os.Getenv("DB_HOST"),
+			os.Getenv("DB_PORT"),
+			os.Getenv("DB_DATABASE"),
+		)
+		return mysql.Open(dsn), nil
+
+	case "sqlite":
+		database := os.Getenv("DB_DATABASE")
+		if database == "" {
+			database = "storage/database.sqlite"
+		}
+
+		if database != ":memory:" {
+			if err := os.MkdirAll(filepath.Dir(database), 0755); err != nil {
+				return nil, err
+			}
+		}
+
+		return sqlite.Open(database + "?_pragma=foreign_keys(1)&_pragma=busy_timeout(5000)&_pragma=journal_mode(WAL)"), nil
+	}
+
+	return nil, fmt.Errorf("unsupported DB_CONNECTION %q, expected mysql or sqlite", connection)
+}
+
 func ConnectDatabase() {
-	var err error
+	connection := os.Getenv("DB_CONNECTION")
 
-	dsn := fmt.Sprintf("%s:%s@tcp(%s:%s)/%s?charset=utf8mb4&parseTime=True&loc=Local",
-		os.Getenv("DB_USERNAME"),
-		os.Getenv("DB_PASSWORD"),
-		os.Getenv("DB_HOST"),
-		os.Getenv("DB_PORT"),
-		os.Getenv("DB_DATABASE"),
-	)
+	dialect, err := dialector(connection)
+	if err != nil {
+		log.Fatal("Failed to connect to database:", err)
+	}
 
 	var gormLogger logger.Interface
 	appEnv := os.Getenv("APP_ENV")
@@ -56,7 +87,7 @@ func ConnectDatabase() {
 		}
 	}
 
-	DB, err = gorm.Open(mysql.Open(dsn), &gorm.Config{
+	DB, err = gorm.Open(dialect, &gorm.Config{
 		Logger: gormLogger,
 	})
 
@@ -69,9 +100,15 @@ func ConnectDatabase() {
 		log.Fatal("Failed to get database instance:", err)
 	}
 
-	sqlDB.SetMaxIdleConns(10)
-	sqlDB.SetMaxOpenConns(100)
-	sqlDB.SetConnMaxLifetime(time.Hour)
+	if connection == "sqlite" {
+		// SQLite has a single writer, and every connection to ":memory:" would
+		// open a separate database.
+		sqlDB.SetMaxOpenConns(1)
+	} else {
+		sqlDB.SetMaxIdleConns(10)
+		sqlDB.SetMaxOpenConns(100)
+		sqlDB.SetConnMaxLifetime(time.Hour)
+	}
 
 	log.Println("Database connected successfully!")
 }
@@ -4,6 +4,8 @@ import (
 	"log"
 	"os"
 	"strconv"
+	"strings"
+	"time"
 
 	"github.com/joho/godotenv"
 )
@@ -20,6 +22,64 @@ var (
 	MailEncryption  string
 	MailFromAddress string
 	MailFromName    string
+
+	FilesystemDisk        string
+	FilesystemRoot        string
+	FilesystemPrivateRoot string
+
+	SignedURLKey string
+	SignedURLTTL time.Duration
+
+	ImageVariantFormats []string
+	ImageEncoderTimeout time.Duration
+	DeduplicateUploads  bool
+	ImageMaxWidth       int
+	ImageMaxHeight      int
+	ImageMaxPixels      int
+
+	BodyLimit  int
+	MaxPerPage int
+
+	UploadAllowedTypes map[string]int64
+
+	TusUploadDir        string
+	TusUploadExpiration time.Duration
+	TusMaxSize          int64
+
+	WorkerConcurrency  int
+	WorkerPollInterval time.Duration
+	JobMaxAttempts     int
+	JobRetryBackoff    time.Duration
+
+	StagingDir              string
+	TrashRetention          time.Duration
+	TrashPurgeInterval      time.Duration
+	QuarantineDir           string
+	TransformCacheDir       string
+	TransformCacheMaxSize   int64
+	TransformAllowedWidths  []int
+	TransformAllowedHeights []int
+	TransformAllowedQuality []int
+
+	AssetImmutablePaths []string
+	AssetCacheMaxAge    int
+	AssetCachePolicies  map[string]int
+
+	FfmpegPath        string
+	FfprobePath       string
+	VideoPosterOffset time.Duration
+	VideoProbeTimeout time.Duration
+
+	PDFRenderTimeout time.Duration
+
+	AwsAccessKeyID     string
+	AwsSecretAccessKey string
+	AwsDefaultRegion   string
+	AwsBucket          string
+	AwsPrivateBucket   string
+	AwsURL             string
+	AwsEndpoint        string
+	AwsUseSSL          bool
 )
 
 func LoadEnv() {
@@ -43,4 +103,190 @@ func LoadEnv() {
 	MailEncryption = os.Getenv("MAIL_ENCRYPTION")
 	MailFromAddress = os.Getenv("MAIL_FROM_ADDRESS")
 	MailFromName = os.Getenv("MAIL_FROM_NAME")
+
+	FilesystemDisk = os.Getenv("FILESYSTEM_DISK")
+	FilesystemRoot = os.Getenv("FILESYSTEM_ROOT")
+	FilesystemPrivateRoot = os.Getenv("FILESYSTEM_PRIVATE_ROOT")
+
+	if FilesystemDisk == "" {
+		FilesystemDisk = "local"
+	}
+
+	if FilesystemRoot == "" {
+		FilesystemRoot = "public"
+	}
+
+	if FilesystemPrivateRoot == "" {
+		FilesystemPrivateRoot = "storage/private"
+	}
+
+	SignedURLKey = os.Getenv("SIGNED_URL_KEY")
+	if SignedURLKey == "" {
+		SignedURLKey = os.Getenv("APP_KEY")
+	}
+
+	SignedURLTTL = time.Duration(envInt("SIGNED_URL_TTL", 3600)) * time.Second
+
+	ImageVariantFormats = parseStringList(os.Getenv("IMAGE_VARIANT_FORMATS"), []string{"webp", "avif"})
+	ImageEncoderTimeout = time.Duration(envInt("IMAGE_ENCODER_TIMEOUT", 60)) * time.Second
+	DeduplicateUploads = os.Getenv("DEDUPLICATE_UPLOADS") == "true"
+
+	ImageMaxWidth = envInt("IMAGE_MAX_WIDTH", 10000)
+	ImageMaxHeight = envInt("IMAGE_MAX_HEIGHT", 10000)
+	ImageMaxPixels = envInt("IMAGE_MAX_PIXELS", 50000000)
+
+	BodyLimit = envInt("BODY_LIMIT", 32) * 1024 * 1024
+
+	UploadAllowedTypes = map[string]int64{}
+	allowed := parseIntMap(os.Getenv("UPLOAD_ALLOWED_TYPES"))
+	if len(allowed) == 0 {
+		allowed = map[string]int{
+			"image/jpeg":      10,
+			"image/png":       10,
+			"image/gif":       10,
+			"image/webp":      10,
+			"application/pdf": 20,
+			"video/mp4":       100,
+			"audio/mpeg":      50,
+			"application/zip": 100,
+			"text/csv":        5,
+		}
+	}
+	for mimeType, size := range allowed {
+		UploadAllowedTypes[mimeType] = int64(size) * 1024 * 1024
+	}
+	MaxPerPage = envInt("MAX_PER_PAGE", 100)
+
+	TusUploadDir = os.Getenv("TUS_UPLOAD_DIR")
+	if TusUploadDir == "" {
+		TusUploadDir = "storage/tus"
+	}
+	TusUploadExpiration = time.Duration(envInt("TUS_UPLOAD_EXPIRATION", 24)) * time.Hour
+	TusMaxSize = int64(envInt("TUS_MAX_SIZE", 100)) * 1024 * 1024
+
+	// Multipart uploads stop at BODY_LIMIT and tus uploads at TUS_MAX_SIZE, a
+	// larger per-type limit could never be reached.
+	maxUploadSize := max(int64(BodyLimit), TusMaxSize)
+	for mimeType, size := range UploadAllowedTypes {
+		if size > maxUploadSize {
+			log.Fatalf("UPLOAD_ALLOWED_TYPES allows %dMB of %s, more than BODY_LIMIT and TUS_MAX_SIZE accept (%dMB)", size>>20, mimeType, maxUploadSize>>20)
+		}
+	}
+
+	WorkerConcurrency = envInt("WORKER_CONCURRENCY", 2)
+	WorkerPollInterval = time.Duration(envInt("WORKER_POLL_INTERVAL", 2)) * time.Second
+	JobMaxAttempts = envInt("JOB_MAX_ATTEMPTS", 5)
+	JobRetryBackoff = time.Duration(envInt("JOB_RETRY_BACKOFF", 10)) * time.Second
+
+	TrashRetention = time.Duration(envIntOrZero("TRASH_RETENTION_DAYS", 30)) * 24 * time.Hour
+	TrashPurgeInterval = time.Duration(envInt("TRASH_PURGE_INTERVAL", 60)) * time.Minute
+
+	StagingDir = os.Getenv("STAGING_DIR")
+	if StagingDir == "" {
+		StagingDir = "storage/staging"
+	}
+
+	QuarantineDir = os.Getenv("QUARANTINE_DIR")
+	if QuarantineDir == "" {
+		QuarantineDir = "storage/quarantine"
+	}
+
+	TransformCacheDir = os.Getenv("TRANSFORM_CACHE_DIR")
+	if TransformCacheDir == "" {
+		TransformCacheDir = "storage/cache"
+	}
+
+	TransformCacheMaxSize = int64(envInt("TRANSFORM_CACHE_MAX_SIZE", 512)) * 1024 * 1024
+
+	TransformAllowedWidths = parseIntList(os.Getenv("TRANSFORM_ALLOWED_WIDTHS"), []int{32, 64, 128, 256, 320, 480, 640, 768, 1024, 1280, 1600, 1920})
+	TransformAllowedHeights = parseIntList(os.Getenv("TRANSFORM_ALLOWED_HEIGHTS"), TransformAllowedWidths)
+	TransformAllowedQuality = parseIntList(os.Getenv("TRANSFORM_ALLOWED_QUALITY"), []int{35, 50, 65, 75, 85, 95})
+
+	AssetImmutablePaths = parseStringList(os.Getenv("ASSET_IMMUTABLE_PATHS"), []string{"images/", "files/"})
+	AssetCacheMaxAge = envIntOrZero("ASSET_CACHE_MAX_AGE", 300)
+	AssetCachePolicies = parseIntMap(os.Getenv("ASSET_CACHE_POLICIES"))
+
+	FfmpegPath = os.Getenv("FFMPEG_PATH")
+	FfprobePath = os.Getenv("FFPROBE_PATH")
+	VideoPosterOffset = time.Duration(envInt("VIDEO_POSTER_OFFSET", 1)) * time.Second
+	VideoProbeTimeout = time.Duration(envInt("VIDEO_PROBE_TIMEOUT", 60)) * time.Second
+	PDFRenderTimeout = time.Duration(envInt("PDF_RENDER_TIMEOUT", 30)) * time.Second
+
+	AwsAccessKeyID = os.Getenv("AWS_ACCESS_KEY_ID")
+	AwsSecretAccessKey = os.Getenv("AWS_SECRET_ACCESS_KEY")
+	AwsDefaultRegion = os.Getenv("AWS_DEFAULT_REGION")
+	AwsBucket = os.Getenv("AWS_BUCKET")
+	AwsPrivateBucket = os.Getenv("AWS_PRIVATE_BUCKET")
+	AwsURL = os.Getenv("AWS_URL")
+	AwsEndpoint = os.Getenv("AWS_ENDPOINT")
+	AwsUseSSL = os.Getenv("AWS_USE_SSL") != "false"
+}
+
+func envInt(key string, fallback int) int {
+	value, err := strconv.Atoi(os.Getenv(key))
+	if err != nil || value <= 0 {
+		return fallback
+	}
+	return value
+}
+
+// envIntOrZero is envInt for settings where 0 turns a feature off: only an
+// unset, invalid or negative value falls back.
+func envIntOrZero(key string, fallback int) int {
+	value, err := strconv.Atoi(os.Getenv(key))
+	if err != nil || value < 0 {
+		return fallback
+	}
+	return value
+}
+
+func parseIntList(value string, fallback []int) []int {
+	if value == "" {
+		return fallback
+	}
+
+	var result []int
+	for _, part := range strings.Split(value, ",") {
+		n, err := strconv.Atoi(strings.TrimSpace(part))
+		if err != nil {
+			log.Printf("Warning: ignoring invalid number %q in list %q\n", part, value)
+			continue
+		}
+		result = append(result, n)
+	}
+
+	if len(result) == 0 {
+		return fallback
+	}
+	return result
+}
+
+func parseStringList(value string, fallback []string) []string {
+	var result []string
+	for _, part := range strings.Split(value, ",") {
+		if part = strings.TrimSpace(part); part != "" {
+			result = append(result, part)
+		}
+	}
+
+	if len(result) == 0 {
+		return fallback
+	}
+	return result
+}
+
+// parseIntMap reads a list of keys with a number each, e.g.
+// "markdown/=86400,favicon.ico=600".
+func parseIntMap(value string) map[string]int {
+	result := map[string]int{}
+	for _, part := range parseStringList(value, nil) {
+		key, number, ok := strings.Cut(part, "=")
+		n, err := strconv.Atoi(strings.TrimSpace(number))
+		if !ok || err != nil || n < 0 {
+			log.Printf("Warning: ignoring invalid entry %q in list %q\n", part, value)
+			continue
+		}
+		result[strings.TrimSpace(key)] = n
+	}
+	return result
 }
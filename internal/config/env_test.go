@@ -0,0 +1,58 @@
+package config
+
+import "testing"
+
+func TestEnvInt(t *testing.T) {
+	tests := []struct {
+		name       string
+		value      string
+		set        bool
+		want       int
+		wantOrZero int
+	}{
+		{"unset", "", false, 30, 30},
+		{"empty", "", true, 30, 30},
+		{"invalid", "forever", true, 30, 30},
+		{"negative", "-1", true, 30, 30},
+		{"zero", "0", true, 30, 0},
+		{"positive", "7", true, 7, 7},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if tt.set {
+				t.Setenv("TEST_ENV_INT", tt.value)
+			}
+
+			if got := envInt("TEST_ENV_INT", 30); got != tt.want {
+				t.Errorf("envInt: got %d, want %d", got, tt.want)
+			}
+			if got := envIntOrZero("TEST_ENV_INT", 30); got != tt.wantOrZero {
+				t.Errorf("envIntOrZero: got %d, want %d", got, tt.wantOrZero)
+			}
+		})
+	}
+}
+
+func TestLoadEnvKeepsTrashForever(t *testing.T) {
+	// Only the server needs a signing key, commands load the same settings.
+	t.Setenv("SIGNED_URL_KEY", "")
+	t.Setenv("APP_KEY", "")
+	t.Setenv("TRASH_RETENTION_DAYS", "0")
+
+	LoadEnv()
+
+	if TrashRetention != 0 {
+		t.Fatalf("got a retention of %s, want 0 to keep trash forever", TrashRetention)
+	}
+}
+
+func TestLoadEnvRevalidatesAssets(t *testing.T) {
+	t.Setenv("ASSET_CACHE_MAX_AGE", "0")
+
+	LoadEnv()
+
+	if AssetCacheMaxAge != 0 {
+		t.Fatalf("got a max-age of %d, want 0 to revalidate every time", AssetCacheMaxAge)
+	}
+}
@@ -0,0 +1,225 @@
+package controllers
+
+import (
+	"container/list"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"nova-cdn/internal/config"
+	"nova-cdn/internal/repositories"
+	"nova-cdn/internal/storage"
+	"nova-cdn/pkg/utils"
+	"path"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	"gorm.io/gorm"
+)
+
+const immutableCacheControl = "public, max-age=31536000, immutable"
+
+// maxAssetETags bounds the in-memory ETag cache, the least recently served
+// files are forgotten first.
+const maxAssetETags = 10000
+
+type AssetController struct {
+	GalleryRepo *repositories.GalleryRepository
+	Storage     storage.Storage
+
+	mu    sync.Mutex
+	order *list.List
+	etags map[string]*list.Element
+}
+
+type assetETag struct {
+	key     string
+	size    int64
+	modTime time.Time
+	value   string
+}
+
+func NewAssetController(db *gorm.DB) *AssetController {
+	return &AssetController{
+		GalleryRepo: repositories.NewGalleryRepository(db),
+		Storage:     storage.GetStorage().Public,
+		order:       list.New(),
+		etags:       map[string]*list.Element{},
+	}
+}
+
+// Show serves a file of the public disk with a strong ETag built from its
+// SHA-256, Last-Modified and the cache policy of its path, answering
+// conditional and Range requests. Unknown paths fall through to the next
+// handler.
+func (ctrl *AssetController) Show(c *fiber.Ctx) error {
+	// Read the path from the URI, ImageNegotiation may have rewritten it.
+	key := strings.TrimPrefix(path.Clean("/"+string(c.Request().URI().Path())), "/")
+	if key == "" || hasDotSegment(key) {
+		return c.Next()
+	}
+
+	info, err := ctrl.Storage.Stat(key)
+	if errors.Is(err, storage.ErrNotFound) {
+		return c.Next()
+	}
+	if err != nil {
+		return utils.ErrorResponse(c, fiber.StatusInternalServerError, "Failed to read file")
+	}
+
+	file, err := ctrl.Storage.Get(key)
+	if errors.Is(err, storage.ErrNotFound) {
+		return c.Next()
+	}
+	if err != nil {
+		return utils.ErrorResponse(c, fiber.StatusInternalServerError, "Failed to read file")
+	}
+
+	etag, err := ctrl.etag(key, info, file)
+	if err != nil {
+		file.Close()
+		return utils.ErrorResponse(c, fiber.StatusInternalServerError, "Failed to read file")
+	}
+
+	contentType := info.ContentType
+	if contentType == "" {
+		contentType = utils.TypeByExtension(path.Ext(key))
+	}
+	if contentType == "" {
+		contentType = fiber.MIMEOctetStream
+	}
+
+	c.Set(fiber.HeaderCacheControl, assetCacheControl(key))
+
+	return serveContent(c, file, info.Size, contentType, etag, info.ModTime)
+}
+
+// etag returns the quoted SHA-256 of the file. It comes from the gallery row
+// of the file when there is one, the file is only hashed when no row knows it
+// and it is new or has changed since it was last seen.
+func (ctrl *AssetController) etag(key string, info *storage.Object, file io.ReadSeeker) (string, error) {
+	if value, ok := ctrl.cachedETag(key, info); ok {
+		return value, nil
+	}
+
+	checksum, err := ctrl.GalleryRepo.FindChecksumByFilePath(key, info.Size)
+	if err != nil {
+		log.Printf("Failed to look up the checksum of %s: %v\n", key, err)
+	}
+
+	if checksum == "" {
+		checksum, err = utils.Checksum(file)
+		if err != nil {
+			return "", err
+		}
+	}
+
+	value := fmt.Sprintf("%q", checksum)
+	ctrl.cacheETag(&assetETag{key: key, size: info.Size, modTime: info.ModTime, value: value})
+
+	return value, nil
+}
+
+func (ctrl *AssetController) cachedETag(key string, info *storage.Object) (string, bool) {
+	ctrl.mu.Lock()
+	defer ctrl.mu.Unlock()
+
+	elem, ok := ctrl.etags[key]
+	if !ok {
+		return "", false
+	}
+
+	cached := elem.Value.(*assetETag)
+	if cached.size != info.Size || !cached.modTime.Equal(info.ModTime) {
+		return "", false
+	}
+
+	ctrl.order.MoveToFront(elem)
+	return cached.value, true
+}
+
+func (ctrl *AssetController) cacheETag(etag *assetETag) {
+	ctrl.mu.Lock()
+	defer ctrl.mu.Unlock()
+
+	if elem, ok := ctrl.etags[etag.key]; ok {
+		ctrl.order.Remove(elem)
+	}
+	ctrl.etags[etag.key] = ctrl.order.PushFront(etag)
+
+	for ctrl.order.Len() > maxAssetETags {
+		elem := ctrl.order.Back()
+		ctrl.order.Remove(elem)
+		delete(ctrl.etags, elem.Value.(*assetETag).key)
+	}
+}
+
+// assetCacheControl picks the Cache-Control header for a key: immutable for
+// content addressed files, otherwise the longest matching configured prefix
+// or the default max-age.
+func assetCacheControl(key string) string {
+	for _, prefix := range config.AssetImmutablePaths {
+		if strings.HasPrefix(key, strings.TrimPrefix(prefix, "/")) {
+			return immutableCacheControl
+		}
+	}
+
+	maxAge, matched := config.AssetCacheMaxAge, ""
+	for prefix, seconds := range config.AssetCachePolicies {
+		prefix = strings.TrimPrefix(prefix, "/")
+		if strings.HasPrefix(key, prefix) && len(prefix) > len(matched) {
+			maxAge, matched = seconds, prefix
+		}
+	}
+
+	if maxAge == 0 {
+		return "public, no-cache"
+	}
+	return fmt.Sprintf("public, max-age=%d", maxAge)
+}
+
+// isNotModified evaluates If-None-Match, or If-Modified-Since when the former
+// is absent, as described in RFC 9110.
+func isNotModified(c *fiber.Ctx, etag string, modTime time.Time) bool {
+	if match := c.Get(fiber.HeaderIfNoneMatch); match != "" {
+		return etagListMatches(match, etag, true)
+	}
+
+	since, err := http.ParseTime(c.Get(fiber.HeaderIfModifiedSince))
+	if err != nil {
+		return false
+	}
+	return !modTime.Truncate(time.Second).After(since)
+}
+
+// etagListMatches reports whether a comma separated list of entity tags, or
+// "*", matches etag. The weak comparison ignores W/ prefixes.
+func etagListMatches(list, etag string, weak bool) bool {
+	for _, candidate := range strings.Split(list, ",") {
+		candidate = strings.TrimSpace(candidate)
+		if candidate == "*" {
+			return true
+		}
+
+		if weak {
+			candidate = strings.TrimPrefix(candidate, "W/")
+		}
+
+		if candidate == etag {
+			return true
+		}
+	}
+	return false
+}
+
+func hasDotSegment(key string) bool {
+	for _, segment := range strings.Split(key, "/") {
+		if strings.HasPrefix(segment, ".") {
+			return true
+		}
+	}
+	return false
+}
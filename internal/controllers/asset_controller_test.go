@@ -0,0 +1,103 @@
+package controllers
+
+import (
+	"container/list"
+	"fmt"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"nova-cdn/internal/config"
+	"nova-cdn/internal/migrations"
+	"nova-cdn/internal/models"
+	"nova-cdn/internal/repositories"
+	"nova-cdn/internal/storage"
+
+	"github.com/glebarez/sqlite"
+	"github.com/gofiber/fiber/v2"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+)
+
+func TestAssetETagUsesStoredChecksum(t *testing.T) {
+	db, err := gorm.Open(sqlite.Open(":memory:"), &gorm.Config{Logger: logger.Default.LogMode(logger.Silent)})
+	if err != nil {
+		t.Fatal(err)
+	}
+	sqlDB, _ := db.DB()
+	sqlDB.SetMaxOpenConns(1)
+
+	migrator, err := migrations.New(db)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := migrator.Up(); err != nil {
+		t.Fatal(err)
+	}
+
+	config.FilesystemDisk = "local"
+	config.FilesystemRoot = t.TempDir()
+	config.FilesystemPrivateRoot = t.TempDir()
+	storage.ConnectStorage()
+
+	ctrl := NewAssetController(db)
+	for _, key := range []string{"images/gallery/known.jpg", "images/gallery/unknown.jpg"} {
+		if err := ctrl.Storage.Put(key, strings.NewReader("data"), 4, "image/jpeg"); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	// The stored checksum wins over the content, proving the file is not hashed.
+	row := &models.Gallery{FilePath: "images/gallery/known.jpg", FileSize: 4, Checksum: "stored", Size: "original", GroupCode: "GL-1"}
+	if err := repositories.NewGalleryRepository(db).CreateMany([]*models.Gallery{row}); err != nil {
+		t.Fatal(err)
+	}
+
+	app := fiber.New()
+	app.Get("/*", ctrl.Show)
+
+	tests := []struct {
+		path string
+		want string
+	}{
+		{"/images/gallery/known.jpg", `"stored"`},
+		{"/images/gallery/unknown.jpg", `"3a6eb0790f39ac87c94f3856b2dd2c5d110e6811602261a9a923d3bb23adc8b7"`},
+	}
+
+	for _, tt := range tests {
+		resp, err := app.Test(httptest.NewRequest("GET", tt.path, nil))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got := resp.Header.Get(fiber.HeaderETag); got != tt.want {
+			t.Errorf("%s: got ETag %s, want %s", tt.path, got, tt.want)
+		}
+	}
+}
+
+func TestAssetETagCacheEvictsLeastRecentlyUsed(t *testing.T) {
+	ctrl := &AssetController{order: list.New(), etags: map[string]*list.Element{}}
+	modTime := time.Now()
+
+	for i := 0; i < maxAssetETags; i++ {
+		key := fmt.Sprintf("file-%d", i)
+		ctrl.cacheETag(&assetETag{key: key, size: 1, modTime: modTime, value: key})
+	}
+
+	// Serving the oldest entry again saves it from the next eviction.
+	if _, ok := ctrl.cachedETag("file-0", &storage.Object{Size: 1, ModTime: modTime}); !ok {
+		t.Fatal("file-0 is not cached")
+	}
+
+	ctrl.cacheETag(&assetETag{key: "new", size: 1, modTime: modTime, value: "new"})
+
+	if len(ctrl.etags) != maxAssetETags {
+		t.Fatalf("cache holds %d entries, want %d", len(ctrl.etags), maxAssetETags)
+	}
+	for key, want := range map[string]bool{"file-0": true, "file-1": false, "file-2": true, "new": true} {
+		if _, ok := ctrl.etags[key]; ok != want {
+			t.Errorf("%s cached: %v, want %v", key, ok, want)
+		}
+	}
+}
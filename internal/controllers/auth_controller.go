@@ -5,7 +5,6 @@ import (
 	"crypto/sha256"
 	"encoding/hex"
 	"fmt"
-	"nova-cdn/internal/config"
 	"nova-cdn/internal/models"
 	"nova-cdn/internal/repositories"
 	"nova-cdn/pkg/utils"
@@ -18,16 +17,21 @@ import (
 )
 
 type AuthController struct {
-	UserRepo *repositories.UserRepository
+	UserRepo  *repositories.UserRepository
+	TokenRepo *repositories.PersonalAccessTokenRepository
 }
 
 type LoginRequest struct {
-	Email    string `json:"email" validate:"required,email"`
-	Password string `json:"password" validate:"required,min=6"`
+	Email     string   `json:"email" validate:"required,email"`
+	Password  string   `json:"password" validate:"required,min=6"`
+	Abilities []string `json:"abilities" example:"gallery:write"`
 }
 
 func NewAuthController(db *gorm.DB) *AuthController {
-	return &AuthController{UserRepo: repositories.NewUserRepository(db)}
+	return &AuthController{
+		UserRepo:  repositories.NewUserRepository(db),
+		TokenRepo: repositories.NewPersonalAccessTokenRepository(db),
+	}
 }
 
 type LoginResponse struct {
@@ -36,7 +40,7 @@ type LoginResponse struct {
 
 // Login godoc
 // @Summary Authenticate a user
-// @Description Login with email and password to receive a personal access token
+// @Description Login with email and password to receive a personal access token. Pass abilities (e.g. ["gallery:write"]) to mint a restricted token, for instance for a server-to-server integration that only uploads; omitted abilities grant "*".
 // @Tags auth
 // @Accept json
 // @Produce json
@@ -58,6 +62,11 @@ func (ctrl *AuthController) Login(c *fiber.Ctx) error {
 		return utils.ValidationError(c, errs)
 	}
 
+	abilities, errs := parseAbilities(data["abilities"])
+	if errs != nil {
+		return utils.ValidationError(c, errs)
+	}
+
 	user, err := ctrl.UserRepo.FindByEmail(data["email"].(string))
 	if err != nil {
 		return utils.ErrorResponse(c, fiber.StatusUnauthorized, "Invalid credentials")
@@ -68,34 +77,86 @@ func (ctrl *AuthController) Login(c *fiber.Ctx) error {
 		return utils.ErrorResponse(c, fiber.StatusUnauthorized, "Invalid credentials")
 	}
 
+	expiration := time.Now().AddDate(0, 0, 7)
+
+	fullToken, _, err := issueToken(ctrl.TokenRepo, user.ID, "auth_token", abilities, &expiration)
+	if err != nil {
+		return utils.ErrorResponse(c, fiber.StatusInternalServerError, "Failed to create token")
+	}
+
+	return utils.SuccessResponse(c, "Login successful", LoginResponse{
+		Token: fullToken,
+	})
+}
+
+// Logout godoc
+// @Summary Log out
+// @Description Revoke the personal access token used for this request
+// @Tags auth
+// @Produce json
+// @Success 200 {object} utils.SimpleResponse
+// @Failure 401 {object} utils.UnauthorizedResponse
+// @Failure 500 {object} utils.SimpleErrorResponse
+// @Router /auth/logout [post]
+// @Security BearerAuth
+func (ctrl *AuthController) Logout(c *fiber.Ctx) error {
+	token := c.Locals("token").(models.PersonalAccessToken)
+
+	if err := ctrl.TokenRepo.Delete(&token); err != nil {
+		return utils.ErrorResponse(c, fiber.StatusInternalServerError, "Failed to revoke token")
+	}
+
+	return utils.SimpleSuccessResponse(c, "Logged out successfully")
+}
+
+// issueToken stores a new token for the user and returns the "<id>|<plain>"
+// value handed to the client. Only the SHA-256 of the plain part is kept.
+func issueToken(repo *repositories.PersonalAccessTokenRepository, userID uint, name string, abilities []string, expiresAt *time.Time) (string, *models.PersonalAccessToken, error) {
 	plainToken := generateRandomToken(40)
 
 	hash := sha256.Sum256([]byte(plainToken))
 	hashedToken := hex.EncodeToString(hash[:])
 
-	expiration := time.Now().AddDate(0, 0, 7)
-
-	db := config.GetDB()
 	token := models.PersonalAccessToken{
 		TokenableType: "App\\Models\\User",
-		TokenableID:   user.ID,
-		Name:          "auth_token",
+		TokenableID:   userID,
+		Name:          name,
 		Token:         hashedToken,
-		Abilities:     "[\"*\"]",
-		ExpiresAt:     &expiration,
+		Abilities:     models.EncodeAbilities(abilities),
+		ExpiresAt:     expiresAt,
 		CreatedAt:     time.Now(),
 		UpdatedAt:     time.Now(),
 	}
 
-	if err := db.Create(&token).Error; err != nil {
-		return utils.ErrorResponse(c, fiber.StatusInternalServerError, "Failed to create token")
+	if err := repo.Create(&token); err != nil {
+		return "", nil, err
 	}
 
-	fullToken := fmt.Sprintf("%d|%s", token.ID, plainToken)
+	return fmt.Sprintf("%d|%s", token.ID, plainToken), &token, nil
+}
 
-	return utils.SuccessResponse(c, "Login successful", LoginResponse{
-		Token: fullToken,
-	})
+// parseAbilities reads the optional abilities field of a JSON body, defaulting
+// to every ability.
+func parseAbilities(value interface{}) ([]string, map[string][]string) {
+	if value == nil {
+		return []string{models.AbilityAll}, nil
+	}
+
+	items, ok := value.([]interface{})
+	if !ok || len(items) == 0 {
+		return nil, map[string][]string{"abilities": {"The abilities field must be a non-empty array"}}
+	}
+
+	abilities := make([]string, 0, len(items))
+	for _, item := range items {
+		ability, ok := item.(string)
+		if !ok || !models.IsValidAbility(ability) {
+			return nil, map[string][]string{"abilities": {fmt.Sprintf("The ability %v is not valid", item)}}
+		}
+		abilities = append(abilities, ability)
+	}
+
+	return abilities, nil
 }
 
 func generateRandomToken(length int) string {
@@ -0,0 +1,269 @@
+package controllers
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"mime/multipart"
+	"net/http"
+	"net/textproto"
+	"nova-cdn/pkg/utils"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+var errUnsatisfiableRange = errors.New("range not satisfiable")
+
+type byteRange struct {
+	start, length int64
+}
+
+func (r byteRange) contentRange(size int64) string {
+	return fmt.Sprintf("bytes %d-%d/%d", r.start, r.start+r.length-1, size)
+}
+
+// serveContent streams file to the client, honouring conditional requests
+// (304), If-Range and single or multiple byte ranges (206, 416). The file is
+// closed once the response has been written. Cache-Control and the like are
+// left to the caller.
+func serveContent(c *fiber.Ctx, file io.ReadSeekCloser, size int64, contentType, etag string, modTime time.Time) error {
+	c.Set(fiber.HeaderAcceptRanges, "bytes")
+
+	if etag != "" {
+		c.Set(fiber.HeaderETag, etag)
+	}
+
+	if !modTime.IsZero() {
+		c.Set(fiber.HeaderLastModified, modTime.UTC().Format(http.TimeFormat))
+	}
+
+	if isNotModified(c, etag, modTime) {
+		file.Close()
+		c.Status(fiber.StatusNotModified)
+		return nil
+	}
+
+	header := c.Get(fiber.HeaderRange)
+	if header == "" || !ifRangeMatches(c, etag, modTime) {
+		c.Set(fiber.HeaderContentType, contentType)
+		return c.SendStream(file, int(size))
+	}
+
+	ranges, err := parseRange(header, size)
+	if errors.Is(err, errUnsatisfiableRange) {
+		file.Close()
+		c.Set(fiber.HeaderContentRange, fmt.Sprintf("bytes */%d", size))
+		return utils.ErrorResponse(c, fiber.StatusRequestedRangeNotSatisfiable, "Requested range not satisfiable")
+	}
+
+	// Malformed headers are ignored, as are range sets larger than the file
+	// itself, which only serve to amplify the response.
+	if err != nil || rangesLength(ranges) > size {
+		c.Set(fiber.HeaderContentType, contentType)
+		return c.SendStream(file, int(size))
+	}
+
+	c.Status(fiber.StatusPartialContent)
+
+	if len(ranges) == 1 {
+		ra := ranges[0]
+		if _, err := file.Seek(ra.start, io.SeekStart); err != nil {
+			file.Close()
+			return utils.ErrorResponse(c, fiber.StatusInternalServerError, "Failed to read file")
+		}
+
+		c.Set(fiber.HeaderContentType, contentType)
+		c.Set(fiber.HeaderContentRange, ra.contentRange(size))
+		return c.SendStream(readCloser{io.LimitReader(file, ra.length), file}, int(ra.length))
+	}
+
+	boundary := multipart.NewWriter(io.Discard).Boundary()
+	length := multipartLength(ranges, boundary, contentType, size)
+
+	c.Set(fiber.HeaderContentType, "multipart/byteranges; boundary="+boundary)
+
+	if c.Method() == fiber.MethodHead {
+		file.Close()
+		return c.SendStream(strings.NewReader(""), int(length))
+	}
+
+	reader, writer := io.Pipe()
+
+	go func() {
+		defer file.Close()
+		writer.CloseWithError(writeRanges(writer, file, ranges, boundary, contentType, size))
+	}()
+
+	return c.SendStream(reader, int(length))
+}
+
+// writeRanges writes the multipart/byteranges body, seeking the file to each
+// range in turn.
+func writeRanges(w io.Writer, file io.ReadSeeker, ranges []byteRange, boundary, contentType string, size int64) error {
+	mw := multipart.NewWriter(w)
+	if err := mw.SetBoundary(boundary); err != nil {
+		return err
+	}
+
+	for _, ra := range ranges {
+		part, err := mw.CreatePart(rangeHeader(ra, contentType, size))
+		if err != nil {
+			return err
+		}
+
+		if _, err := file.Seek(ra.start, io.SeekStart); err != nil {
+			return err
+		}
+
+		if _, err := io.CopyN(part, file, ra.length); err != nil {
+			return err
+		}
+	}
+
+	return mw.Close()
+}
+
+// multipartLength computes the Content-Length of a multipart/byteranges body
+// by writing its headers alone.
+func multipartLength(ranges []byteRange, boundary, contentType string, size int64) int64 {
+	var counter countingWriter
+
+	mw := multipart.NewWriter(&counter)
+	mw.SetBoundary(boundary)
+
+	var body int64
+	for _, ra := range ranges {
+		mw.CreatePart(rangeHeader(ra, contentType, size))
+		body += ra.length
+	}
+	mw.Close()
+
+	return int64(counter) + body
+}
+
+func rangeHeader(ra byteRange, contentType string, size int64) textproto.MIMEHeader {
+	return textproto.MIMEHeader{
+		fiber.HeaderContentType:  {contentType},
+		fiber.HeaderContentRange: {ra.contentRange(size)},
+	}
+}
+
+// parseRange parses a "bytes=" Range header against a file of the given size.
+// Ranges starting past the end are dropped; errUnsatisfiableRange is returned
+// when none is left.
+func parseRange(header string, size int64) ([]byteRange, error) {
+	spec, ok := strings.CutPrefix(header, "bytes=")
+	if !ok {
+		return nil, errors.New("invalid range unit")
+	}
+
+	var ranges []byteRange
+	noOverlap := false
+
+	for _, part := range strings.Split(spec, ",") {
+		part = strings.TrimSpace(part)
+		if part == "" {
+			continue
+		}
+
+		first, last, ok := strings.Cut(part, "-")
+		if !ok {
+			return nil, errors.New("invalid range")
+		}
+		first, last = strings.TrimSpace(first), strings.TrimSpace(last)
+
+		var ra byteRange
+
+		if first == "" {
+			// A suffix range, the last N bytes of the file.
+			n, err := strconv.ParseInt(last, 10, 64)
+			if err != nil || n < 0 {
+				return nil, errors.New("invalid range")
+			}
+
+			if n == 0 || size == 0 {
+				noOverlap = true
+				continue
+			}
+
+			if n > size {
+				n = size
+			}
+			ra = byteRange{start: size - n, length: n}
+		} else {
+			start, err := strconv.ParseInt(first, 10, 64)
+			if err != nil || start < 0 {
+				return nil, errors.New("invalid range")
+			}
+
+			if start >= size {
+				noOverlap = true
+				continue
+			}
+
+			end := size - 1
+			if last != "" {
+				end, err = strconv.ParseInt(last, 10, 64)
+				if err != nil || end < start {
+					return nil, errors.New("invalid range")
+				}
+
+				if end >= size {
+					end = size - 1
+				}
+			}
+			ra = byteRange{start: start, length: end - start + 1}
+		}
+
+		ranges = append(ranges, ra)
+	}
+
+	if len(ranges) == 0 {
+		if noOverlap {
+			return nil, errUnsatisfiableRange
+		}
+		return nil, errors.New("invalid range")
+	}
+
+	return ranges, nil
+}
+
+func rangesLength(ranges []byteRange) int64 {
+	var length int64
+	for _, ra := range ranges {
+		length += ra.length
+	}
+	return length
+}
+
+// ifRangeMatches reports whether a Range header may be honoured: If-Range is
+// absent, or names the current entity tag (strong comparison) or the exact
+// Last-Modified date.
+func ifRangeMatches(c *fiber.Ctx, etag string, modTime time.Time) bool {
+	value := c.Get(fiber.HeaderIfRange)
+	if value == "" {
+		return true
+	}
+
+	if strings.HasPrefix(value, `"`) || strings.HasPrefix(value, "W/") {
+		return etag != "" && value == etag
+	}
+
+	since, err := http.ParseTime(value)
+	return err == nil && !modTime.IsZero() && modTime.Truncate(time.Second).Equal(since)
+}
+
+type readCloser struct {
+	io.Reader
+	io.Closer
+}
+
+type countingWriter int64
+
+func (w *countingWriter) Write(p []byte) (int, error) {
+	*w += countingWriter(len(p))
+	return len(p), nil
+}
@@ -14,10 +14,16 @@ package controllers
 
 import (
 	"fmt"
+	"nova-cdn/internal/config"
+	"nova-cdn/internal/models"
 	"nova-cdn/internal/repositories"
 	"nova-cdn/internal/service"
+	"nova-cdn/internal/signing"
+	"nova-cdn/internal/storage"
 	"nova-cdn/pkg/utils"
 	"strconv"
+	"strings"
+	"time"
 
 	"github.com/gofiber/fiber/v2"
 	"gorm.io/gorm"
@@ -26,17 +32,51 @@ import (
 type GalleryController struct {
 	GalleryRepo    *repositories.GalleryRepository
 	GenerateRepo   *repositories.GenerateRepository
+	ImageJobRepo   *repositories.ImageJobRepository
 	GalleryService service.GalleryService
+	Storage        *storage.Disks
 }
 
 func NewGalleryController(db *gorm.DB) *GalleryController {
 	return &GalleryController{
 		GalleryRepo:    repositories.NewGalleryRepository(db),
 		GenerateRepo:   repositories.NewGenerateRepository(db),
+		ImageJobRepo:   repositories.NewImageJobRepository(db),
 		GalleryService: service.NewGalleryService(db),
+		Storage:        storage.GetStorage(),
 	}
 }
 
+// parsePage reads the page and per_page query parameters, capping per_page at
+// MAX_PER_PAGE.
+func parsePage(c *fiber.Ctx) (int, int) {
+	page, _ := strconv.Atoi(c.Query("page", "1"))
+	perPage, _ := strconv.Atoi(c.Query("per_page", "10"))
+
+	if page < 1 {
+		page = 1
+	}
+
+	if perPage < 1 {
+		perPage = 10
+	}
+
+	if config.MaxPerPage > 0 && perPage > config.MaxPerPage {
+		perPage = config.MaxPerPage
+	}
+
+	return page, perPage
+}
+
+// galleries returns the gallery repository scoped to the authenticated user.
+// Admins see the galleries of every user.
+func (ctrl *GalleryController) galleries(c *fiber.Ctx) *repositories.GalleryRepository {
+	if isAdmin, _ := c.Locals("is_admin").(bool); isAdmin {
+		return ctrl.GalleryRepo
+	}
+	return ctrl.GalleryRepo.ForUser(c.Locals("user_id").(uint))
+}
+
 // Index godoc
 // @Summary List galleries
 // @Description Get a paginated list of galleries
@@ -44,36 +84,50 @@ func NewGalleryController(db *gorm.DB) *GalleryController {
 // @Accept json
 // @Produce json
 // @Param page query int false "Page number" default(1)
-// @Param per_page query int false "Items per page" default(10)
+// @Param per_page query int false "Items per page, capped at MAX_PER_PAGE" default(10)
+// @Param pagination query string false "Use keyset pagination, the meta then holds next_cursor and prev_cursor instead of page counts" Enums(offset, cursor)
+// @Param cursor query string false "next_cursor or prev_cursor from a previous cursor page, implies pagination=cursor"
 // @Param subject_id query string false "Subject ID"
 // @Param subject_type query string false "Subject Type"
 // @Param size query string false "Size (original, small, medium, large)"
+// @Param trashed query string false "Include soft deleted galleries (with) or list them alone (only)" Enums(with, only)
+// @Param user_id query int false "Owner user ID, only useful to admins"
+// @Param is_private query bool false "Private or public galleries only"
+// @Param q query string false "Text searched in the description"
+// @Param mime_type query string false "Stored MIME type, e.g. image/webp"
+// @Param created_from query string false "Created at or after this date (YYYY-MM-DD or RFC 3339)"
+// @Param created_to query string false "Created at or before this date (YYYY-MM-DD or RFC 3339)"
+// @Param min_width query int false "Minimum width in pixels"
+// @Param max_width query int false "Maximum width in pixels"
+// @Param min_height query int false "Minimum height in pixels"
+// @Param max_height query int false "Maximum height in pixels"
+// @Param min_file_size query int false "Minimum file size in bytes"
+// @Param max_file_size query int false "Maximum file size in bytes"
+// @Param sort query string false "Indexed column to sort by, prefixed with - for descending order" Enums(id, -id, user_id, -user_id, group_code, -group_code, checksum, -checksum, file_size, -file_size, file_path, -file_path, created_at, -created_at, deleted_at, -deleted_at)
 // @Success 200 {object} utils.PaginatedResponse{data=[]GallerySwagger}
 // @Failure 400 {object} utils.SimpleErrorResponse
+// @Failure 403 {object} utils.SimpleErrorResponse
 // @Router /galleries [get]
 // @Security BearerAuth
 func (ctrl *GalleryController) Index(c *fiber.Ctx) error {
-	page, _ := strconv.Atoi(c.Query("page", "1"))
-	perPage, _ := strconv.Atoi(c.Query("per_page", "10"))
-	subject_id := c.Query("subject_id", "")
-	subject_type := c.Query("subject_type", "")
-	size := c.Query("size", "")
+	page, perPage := parsePage(c)
 
-	if page < 1 {
-		page = 1
+	filter, err := parseGalleryFilter(c)
+	if err != nil {
+		return utils.ErrorResponse(c, fiber.StatusBadRequest, err.Error())
 	}
 
-	if perPage < 1 {
-		perPage = 10
+	if c.Query("pagination") == "cursor" || c.Query("cursor") != "" {
+		return ctrl.cursorIndex(c, filter, perPage)
 	}
 
-	total, err := ctrl.GalleryRepo.Count(subject_id, subject_type, size)
+	total, err := ctrl.galleries(c).Count(filter)
 
 	if err != nil {
 		return utils.ErrorResponse(c, fiber.StatusBadRequest, "Failed to count galleries")
 	}
 
-	galleries, err := ctrl.GalleryRepo.FindAllPaginated(page, perPage, subject_id, subject_type, size)
+	galleries, err := ctrl.galleries(c).FindAllPaginated(filter, page, perPage)
 
 	if err != nil {
 		return utils.ErrorResponse(c, fiber.StatusBadRequest, "Failed to retrieve galleries")
@@ -86,9 +140,108 @@ func (ctrl *GalleryController) Index(c *fiber.Ctx) error {
 	return utils.PaginatedSuccessResponse(c, "Galleries retrieved successfully", galleries, page, perPage, total, len(galleries))
 }
 
+// cursorIndex serves Index with keyset pagination, which stays fast on deep
+// pages and skips the count query.
+func (ctrl *GalleryController) cursorIndex(c *fiber.Ctx, filter repositories.GalleryFilter, perPage int) error {
+	column, _ := filter.SortColumn()
+	if !repositories.GalleryCursorColumns[column] || (column == "deleted_at" && filter.Trashed != "only") {
+		return utils.ErrorResponse(c, fiber.StatusBadRequest, "Sort "+column+" is not supported with cursor pagination")
+	}
+
+	var cursor *repositories.GalleryCursor
+	if token := c.Query("cursor"); token != "" {
+		decoded, err := repositories.DecodeGalleryCursor(token)
+		if err != nil {
+			return utils.ErrorResponse(c, fiber.StatusBadRequest, "Invalid cursor")
+		}
+
+		if decoded.Sort != filter.Sort {
+			return utils.ErrorResponse(c, fiber.StatusBadRequest, "Cursor does not match the requested sort")
+		}
+
+		cursor = decoded
+	}
+
+	galleries, hasMore, err := ctrl.galleries(c).FindPage(filter, cursor, perPage)
+
+	if err != nil {
+		return utils.ErrorResponse(c, fiber.StatusBadRequest, "Failed to retrieve galleries")
+	}
+
+	if len(galleries) < 1 {
+		return utils.ErrorResponse(c, fiber.StatusNotFound, "No galleries found")
+	}
+
+	backward := cursor != nil && cursor.Backward
+	first, last := galleries[0], galleries[len(galleries)-1]
+
+	var next, prev string
+	if hasMore || backward {
+		next = repositories.NewGalleryCursor(last, filter.Sort, false).Encode()
+	}
+	if (hasMore && backward) || (cursor != nil && !backward) {
+		prev = repositories.NewGalleryCursor(first, filter.Sort, true).Encode()
+	}
+
+	return utils.CursorPaginatedSuccessResponse(c, "Galleries retrieved successfully", galleries, perPage, len(galleries), next, prev)
+}
+
+type TrashedGallery struct {
+	models.Gallery
+	PurgeAt *time.Time `json:"purge_at"`
+}
+
+// Trash godoc
+// @Summary List trashed galleries
+// @Description Get a paginated list of soft deleted galleries, newest first, with the date each one will be permanently deleted (null when the trash is never purged)
+// @Tags galleries
+// @Accept json
+// @Produce json
+// @Param page query int false "Page number" default(1)
+// @Param per_page query int false "Items per page, capped at MAX_PER_PAGE" default(10)
+// @Success 200 {object} utils.PaginatedResponse{data=[]TrashedGallerySwagger}
+// @Failure 400 {object} utils.SimpleErrorResponse
+// @Failure 404 {object} utils.SimpleErrorResponse
+// @Failure 403 {object} utils.SimpleErrorResponse
+// @Router /galleries/trash [get]
+// @Security BearerAuth
+func (ctrl *GalleryController) Trash(c *fiber.Ctx) error {
+	page, perPage := parsePage(c)
+
+	filter := repositories.GalleryFilter{Trashed: "only", Sort: "-deleted_at"}
+
+	total, err := ctrl.galleries(c).Count(filter)
+
+	if err != nil {
+		return utils.ErrorResponse(c, fiber.StatusBadRequest, "Failed to count trashed galleries")
+	}
+
+	galleries, err := ctrl.galleries(c).FindAllPaginated(filter, page, perPage)
+
+	if err != nil {
+		return utils.ErrorResponse(c, fiber.StatusBadRequest, "Failed to retrieve trashed galleries")
+	}
+
+	if len(galleries) < 1 {
+		return utils.ErrorResponse(c, fiber.StatusNotFound, "No trashed galleries found")
+	}
+
+	trashed := make([]TrashedGallery, 0, len(galleries))
+	for _, gallery := range galleries {
+		item := TrashedGallery{Gallery: gallery}
+		if config.TrashRetention > 0 && gallery.DeletedAt.Valid {
+			purgeAt := gallery.DeletedAt.Time.Add(config.TrashRetention)
+			item.PurgeAt = &purgeAt
+		}
+		trashed = append(trashed, item)
+	}
+
+	return utils.PaginatedSuccessResponse(c, "Trashed galleries retrieved successfully", trashed, page, perPage, total, len(trashed))
+}
+
 // Upload godoc
 // @Summary Upload image to gallery
-// @Description Upload a new image to the gallery with optimized versions
+// @Description Upload a new image to the gallery, optimized versions are generated in the background
 // @Tags galleries
 // @Accept multipart/form-data
 // @Produce json
@@ -98,17 +251,85 @@ func (ctrl *GalleryController) Index(c *fiber.Ctx) error {
 // @Param dir formData string false "Directory name (gallery, payment, item, etc.)" default(gallery)
 // @Param description formData string false "Image description"
 // @Param is_private formData boolean false "Set image as private" default(false)
-// @Success 201 {object} utils.Response{data=[]GallerySwagger}
+// @Success 202 {object} utils.Response{data=[]GallerySwagger}
 // @Failure 400 {object} utils.SimpleErrorResponse
 // @Failure 401 {object} utils.UnauthorizedResponse
 // @Failure 404 {object} utils.SimpleErrorResponse
 // @Failure 500 {object} utils.SimpleErrorResponse
+// @Failure 403 {object} utils.SimpleErrorResponse
 // @Router /galleries/upload [post]
 // @Security BearerAuth
 func (ctrl *GalleryController) Upload(c *fiber.Ctx) error {
 	return ctrl.GalleryService.Upload(c)
 }
 
+// UploadFile godoc
+// @Summary Upload a file to gallery
+// @Description Upload any allowed file type (PDF, MP4, MP3, ZIP, CSV, ...) with a per-type size limit. The type is sniffed from the content; images, PDFs and videos (when ffmpeg is installed) also get optimized versions in the background (202), PDFs from their first page and videos from a poster frame along with their duration, resolution, codec and bitrate; other files are stored as they are (201)
+// @Tags galleries
+// @Accept multipart/form-data
+// @Produce json
+// @Param file formData file true "File to upload"
+// @Param subject_id formData int false "Subject ID"
+// @Param subject_type formData string false "Subject Type"
+// @Param dir formData string false "Directory name (gallery, payment, item, etc.)" default(gallery)
+// @Param description formData string false "File description"
+// @Param is_private formData boolean false "Set file as private" default(false)
+// @Success 201 {object} utils.Response{data=[]GallerySwagger}
+// @Success 202 {object} utils.Response{data=[]GallerySwagger}
+// @Failure 400 {object} utils.SimpleErrorResponse
+// @Failure 401 {object} utils.UnauthorizedResponse
+// @Failure 403 {object} utils.SimpleErrorResponse
+// @Failure 413 {object} utils.SimpleErrorResponse
+// @Failure 415 {object} utils.SimpleErrorResponse
+// @Failure 500 {object} utils.SimpleErrorResponse
+// @Router /galleries/files [post]
+// @Security BearerAuth
+func (ctrl *GalleryController) UploadFile(c *fiber.Ctx) error {
+	return ctrl.GalleryService.UploadFile(c)
+}
+
+// Status godoc
+// @Summary Show processing status by group code
+// @Description Show the status of the optimized versions generation for a group code
+// @Tags galleries
+// @Accept json
+// @Produce json
+// @Param group_code path string true "Group Code"
+// @Success 200 {object} utils.Response{data=ProcessingStatusResponse}
+// @Failure 404 {object} utils.SimpleErrorResponse
+// @Failure 403 {object} utils.SimpleErrorResponse
+// @Router /galleries/{group_code}/status [get]
+// @Security BearerAuth
+func (ctrl *GalleryController) Status(c *fiber.Ctx) error {
+	groupCode := c.Params("group_code")
+
+	originals, err := ctrl.galleries(c).FindByGroupCode(groupCode, "original")
+	if err != nil || len(originals) < 1 {
+		return utils.ErrorResponse(c, fiber.StatusNotFound, "Gallery not found")
+	}
+
+	original := originals[0]
+
+	response := ProcessingStatusResponse{
+		GroupCode:    groupCode,
+		Status:       models.JobStatusDone,
+		HasOptimized: original.HasOptimized,
+	}
+
+	job, err := ctrl.ImageJobRepo.FindByGroupCode(groupCode)
+	if err == nil {
+		response.Status = job.Status
+		response.Attempts = job.Attempts
+		response.LastError = job.LastError
+		response.FinishedAt = job.FinishedAt
+	} else if !original.HasOptimized {
+		response.Status = models.JobStatusPending
+	}
+
+	return utils.SuccessResponse(c, "Processing status retrieved successfully", response)
+}
+
 // Destroy godoc
 // @Summary Delete a gallery item (Soft Delete)
 // @Description Move a gallery item to trash
@@ -119,6 +340,8 @@ func (ctrl *GalleryController) Upload(c *fiber.Ctx) error {
 // @Success 200 {object} utils.SimpleResponse
 // @Failure 400 {object} utils.SimpleErrorResponse
 // @Failure 500 {object} utils.SimpleErrorResponse
+// @Failure 404 {object} utils.SimpleErrorResponse
+// @Failure 403 {object} utils.SimpleErrorResponse
 // @Router /galleries/{id} [delete]
 // @Security BearerAuth
 func (ctrl *GalleryController) Destroy(c *fiber.Ctx) error {
@@ -127,13 +350,13 @@ func (ctrl *GalleryController) Destroy(c *fiber.Ctx) error {
 		return utils.ErrorResponse(c, fiber.StatusBadRequest, "Invalid gallery ID")
 	}
 
-	gallery, err := ctrl.GalleryRepo.FindByID(uint64(id), false)
+	gallery, err := ctrl.galleries(c).FindByID(uint64(id), false)
 
 	if err != nil {
-		return utils.ErrorResponse(c, fiber.StatusBadRequest, "Gallery not found")
+		return utils.ErrorResponse(c, fiber.StatusNotFound, "Gallery not found")
 	}
 
-	if err := ctrl.GalleryRepo.Delete(gallery); err != nil {
+	if err := ctrl.galleries(c).Delete(gallery); err != nil {
 		return utils.ErrorResponse(c, fiber.StatusInternalServerError, "Failed to delete gallery")
 	}
 
@@ -150,6 +373,8 @@ func (ctrl *GalleryController) Destroy(c *fiber.Ctx) error {
 // @Success 200 {object} utils.SimpleResponse
 // @Failure 400 {object} utils.SimpleErrorResponse
 // @Failure 500 {object} utils.SimpleErrorResponse
+// @Failure 404 {object} utils.SimpleErrorResponse
+// @Failure 403 {object} utils.SimpleErrorResponse
 // @Router /galleries/{id}/force [delete]
 // @Security BearerAuth
 func (ctrl *GalleryController) ForceDelete(c *fiber.Ctx) error {
@@ -158,17 +383,17 @@ func (ctrl *GalleryController) ForceDelete(c *fiber.Ctx) error {
 		return utils.ErrorResponse(c, fiber.StatusBadRequest, "Invalid gallery ID")
 	}
 
-	gallery, err := ctrl.GalleryRepo.FindByID(uint64(id), true)
+	gallery, err := ctrl.galleries(c).FindByID(uint64(id), true)
 
 	if err != nil {
-		return utils.ErrorResponse(c, fiber.StatusBadRequest, "Gallery not found")
+		return utils.ErrorResponse(c, fiber.StatusNotFound, "Gallery not found")
 	}
 
-	if err := ctrl.GalleryRepo.ForceDelete(gallery); err != nil {
+	if err := ctrl.galleries(c).ForceDelete(gallery); err != nil {
 		return utils.ErrorResponse(c, fiber.StatusInternalServerError, "Failed to delete gallery")
 	}
 
-	utils.RemoveImageFiles(gallery.FilePath)
+	ctrl.GalleryService.RemoveFiles([]models.Gallery{*gallery})
 
 	return utils.SimpleSuccessResponse(c, "Gallery deleted successfully")
 }
@@ -184,6 +409,7 @@ func (ctrl *GalleryController) ForceDelete(c *fiber.Ctx) error {
 // @Failure 401 {object} utils.SimpleErrorResponse
 // @Failure 400 {object} utils.SimpleErrorResponse
 // @Failure 404 {object} utils.SimpleErrorResponse
+// @Failure 403 {object} utils.SimpleErrorResponse
 // @Router /galleries/{id}/restore [post]
 // @Security BearerAuth
 func (ctrl *GalleryController) Restore(c *fiber.Ctx) error {
@@ -193,13 +419,13 @@ func (ctrl *GalleryController) Restore(c *fiber.Ctx) error {
 		return utils.ErrorResponse(c, fiber.StatusBadRequest, "Invalid gallery ID")
 	}
 
-	gallery, err := ctrl.GalleryRepo.FindByID(uint64(id), true)
+	gallery, err := ctrl.galleries(c).FindByID(uint64(id), true)
 
 	if err != nil {
-		return utils.ErrorResponse(c, fiber.StatusBadRequest, "Gallery not found")
+		return utils.ErrorResponse(c, fiber.StatusNotFound, "Gallery not found")
 	}
 
-	if err := ctrl.GalleryRepo.Restore(gallery); err != nil {
+	if err := ctrl.galleries(c).Restore(gallery); err != nil {
 		return utils.ErrorResponse(c, fiber.StatusInternalServerError, "Failed to restore gallery")
 	}
 
@@ -217,16 +443,23 @@ func (ctrl *GalleryController) Restore(c *fiber.Ctx) error {
 // @Failure 401 {object} utils.SimpleErrorResponse
 // @Failure 400 {object} utils.SimpleErrorResponse
 // @Failure 404 {object} utils.SimpleErrorResponse
+// @Failure 403 {object} utils.SimpleErrorResponse
 // @Router /galleries/{group_code}/restore [post]
 // @Security BearerAuth
 func (ctrl *GalleryController) RestoreByGroupCode(c *fiber.Ctx) error {
 	groupCode := c.Params("group_code")
 
-	if err := ctrl.GalleryRepo.RestoreByGroupCode(groupCode, ""); err != nil {
+	restored, err := ctrl.galleries(c).RestoreByGroupCode(groupCode, "")
+
+	if err != nil {
 		fmt.Println(err)
 		return utils.ErrorResponse(c, fiber.StatusInternalServerError, "Failed to restore gallery")
 	}
 
+	if restored == 0 {
+		return utils.ErrorResponse(c, fiber.StatusNotFound, "Gallery not found")
+	}
+
 	return utils.SimpleSuccessResponse(c, "Gallery restored successfully")
 }
 
@@ -240,6 +473,8 @@ func (ctrl *GalleryController) RestoreByGroupCode(c *fiber.Ctx) error {
 // @Success 200 {object} utils.Response{data=GallerySwagger}
 // @Failure 400 {object} utils.SimpleErrorResponse
 // @Failure 500 {object} utils.SimpleErrorResponse
+// @Failure 404 {object} utils.SimpleErrorResponse
+// @Failure 403 {object} utils.SimpleErrorResponse
 // @Router /galleries/{id} [get]
 // @Security BearerAuth
 func (ctrl *GalleryController) Show(c *fiber.Ctx) error {
@@ -249,10 +484,10 @@ func (ctrl *GalleryController) Show(c *fiber.Ctx) error {
 		return utils.ErrorResponse(c, fiber.StatusBadRequest, "Invalid gallery ID")
 	}
 
-	gallery, err := ctrl.GalleryRepo.FindByID(uint64(id), false)
+	gallery, err := ctrl.galleries(c).FindByID(uint64(id), false)
 
 	if err != nil {
-		return utils.ErrorResponse(c, fiber.StatusBadRequest, "Gallery not found")
+		return utils.ErrorResponse(c, fiber.StatusNotFound, "Gallery not found")
 	}
 
 	return utils.SuccessResponse(c, "Gallery retrieved successfully", gallery)
@@ -266,19 +501,27 @@ func (ctrl *GalleryController) Show(c *fiber.Ctx) error {
 // @Produce json
 // @Param group_code path string true "Group Code"
 // @Param size query string false "Size (original, small, medium, large)"
+// @Param trashed query string false "Include soft deleted galleries (with) or list them alone (only)" Enums(with, only)
 // @Success 200 {object} utils.Response{data=GallerySwagger}
 // @Failure 400 {object} utils.SimpleErrorResponse
 // @Failure 500 {object} utils.SimpleErrorResponse
+// @Failure 404 {object} utils.SimpleErrorResponse
+// @Failure 403 {object} utils.SimpleErrorResponse
 // @Router /galleries/{group_code} [get]
 // @Security BearerAuth
 func (ctrl *GalleryController) ShowByGroupCode(c *fiber.Ctx) error {
 	groupCode := c.Params("group_code")
 	size := c.Query("size", "")
+	trashed := c.Query("trashed", "")
+
+	if !repositories.TrashedFilters[trashed] {
+		return utils.ErrorResponse(c, fiber.StatusBadRequest, "Invalid trashed filter, expected with or only")
+	}
 
-	galleries, err := ctrl.GalleryRepo.FindByGroupCode(groupCode, size)
+	galleries, err := ctrl.galleries(c).FindByGroupCodeWithTrashed(groupCode, size, trashed)
 
-	if err != nil {
-		return utils.ErrorResponse(c, fiber.StatusBadRequest, "Gallery not found")
+	if err != nil || len(galleries) == 0 {
+		return utils.ErrorResponse(c, fiber.StatusNotFound, "Gallery not found")
 	}
 
 	return utils.SuccessResponse(c, "Galleries retrieved successfully", galleries)
@@ -295,16 +538,24 @@ func (ctrl *GalleryController) ShowByGroupCode(c *fiber.Ctx) error {
 // @Success 200 {object} utils.SimpleResponse
 // @Failure 400 {object} utils.SimpleErrorResponse
 // @Failure 500 {object} utils.SimpleErrorResponse
+// @Failure 404 {object} utils.SimpleErrorResponse
+// @Failure 403 {object} utils.SimpleErrorResponse
 // @Router /galleries/{group_code} [delete]
 // @Security BearerAuth
 func (ctrl *GalleryController) DestroyByGroupCode(c *fiber.Ctx) error {
 	groupCode := c.Params("group_code")
 	size := c.Query("size", "")
 
-	if err := ctrl.GalleryRepo.DeleteByGroupCode(groupCode, size); err != nil {
+	deleted, err := ctrl.galleries(c).DeleteByGroupCode(groupCode, size)
+
+	if err != nil {
 		return utils.ErrorResponse(c, fiber.StatusInternalServerError, "Failed to delete galleries")
 	}
 
+	if deleted == 0 {
+		return utils.ErrorResponse(c, fiber.StatusNotFound, "Gallery not found")
+	}
+
 	return utils.SimpleSuccessResponse(c, "Galleries deleted successfully")
 }
 
@@ -319,20 +570,269 @@ func (ctrl *GalleryController) DestroyByGroupCode(c *fiber.Ctx) error {
 // @Success 200 {object} utils.SimpleResponse
 // @Failure 400 {object} utils.SimpleErrorResponse
 // @Failure 500 {object} utils.SimpleErrorResponse
+// @Failure 404 {object} utils.SimpleErrorResponse
+// @Failure 403 {object} utils.SimpleErrorResponse
 // @Router /galleries/{group_code}/force [delete]
 // @Security BearerAuth
 func (ctrl *GalleryController) ForceDeleteByGroupCode(c *fiber.Ctx) error {
 	groupCode := c.Params("group_code")
 	size := c.Query("size", "")
 
-	galleries, err := ctrl.GalleryRepo.ForceDeleteByGroupCode(groupCode, size)
+	galleries, err := ctrl.galleries(c).ForceDeleteByGroupCode(groupCode, size)
 	if err != nil {
 		return utils.ErrorResponse(c, fiber.StatusInternalServerError, "Failed to delete galleries")
 	}
 
-	for _, gallery := range galleries {
-		utils.RemoveImageFiles(gallery.FilePath)
+	if len(galleries) == 0 {
+		return utils.ErrorResponse(c, fiber.StatusNotFound, "Gallery not found")
 	}
 
+	ctrl.GalleryService.RemoveFiles(galleries)
+
 	return utils.SimpleSuccessResponse(c, "Galleries deleted successfully")
 }
+
+// parseGalleryFilter reads the listing filters from the query string.
+func parseGalleryFilter(c *fiber.Ctx) (repositories.GalleryFilter, error) {
+	filter := repositories.GalleryFilter{
+		SubjectID:   c.Query("subject_id", ""),
+		SubjectType: c.Query("subject_type", ""),
+		Size:        c.Query("size", ""),
+		Search:      c.Query("q", ""),
+		Trashed:     c.Query("trashed", ""),
+		Sort:        c.Query("sort", ""),
+	}
+
+	if !repositories.TrashedFilters[filter.Trashed] {
+		return filter, fmt.Errorf("invalid trashed filter, expected with or only")
+	}
+
+	if column := strings.TrimPrefix(filter.Sort, "-"); filter.Sort != "" && !repositories.GallerySortColumns[column] {
+		return filter, fmt.Errorf("invalid sort column %s", column)
+	}
+
+	if mimeType := c.Query("mime_type", ""); mimeType != "" {
+		filter.MimeType = mimeType
+	}
+
+	if value := c.Query("user_id", ""); value != "" {
+		userID, err := strconv.ParseUint(value, 10, 32)
+		if err != nil {
+			return filter, fmt.Errorf("invalid user_id")
+		}
+		id := uint(userID)
+		filter.UserID = &id
+	}
+
+	if value := c.Query("is_private", ""); value != "" {
+		isPrivate, err := strconv.ParseBool(value)
+		if err != nil {
+			return filter, fmt.Errorf("invalid is_private, expected true or false")
+		}
+		filter.IsPrivate = &isPrivate
+	}
+
+	var err error
+
+	if filter.CreatedFrom, err = parseDateQuery(c, "created_from", false); err != nil {
+		return filter, err
+	}
+
+	if filter.CreatedTo, err = parseDateQuery(c, "created_to", true); err != nil {
+		return filter, err
+	}
+
+	ranges := map[string]**uint64{
+		"min_width":     &filter.MinWidth,
+		"max_width":     &filter.MaxWidth,
+		"min_height":    &filter.MinHeight,
+		"max_height":    &filter.MaxHeight,
+		"min_file_size": &filter.MinFileSize,
+		"max_file_size": &filter.MaxFileSize,
+	}
+
+	for key, target := range ranges {
+		value := c.Query(key, "")
+		if value == "" {
+			continue
+		}
+
+		number, err := strconv.ParseUint(value, 10, 64)
+		if err != nil {
+			return filter, fmt.Errorf("invalid %s, expected a positive integer", key)
+		}
+		*target = &number
+	}
+
+	return filter, nil
+}
+
+// parseDateQuery accepts a date or an RFC 3339 timestamp. A plain date used as
+// an upper bound covers the whole day.
+func parseDateQuery(c *fiber.Ctx, key string, endOfDay bool) (*time.Time, error) {
+	value := c.Query(key, "")
+	if value == "" {
+		return nil, nil
+	}
+
+	if t, err := time.Parse(time.RFC3339, value); err == nil {
+		return &t, nil
+	}
+
+	t, err := time.ParseInLocation("2006-01-02", value, time.Local)
+	if err != nil {
+		return nil, fmt.Errorf("invalid %s, expected YYYY-MM-DD or RFC 3339", key)
+	}
+
+	if endOfDay {
+		t = t.Add(24*time.Hour - time.Nanosecond)
+	}
+	return &t, nil
+}
+
+type ProcessingStatusResponse struct {
+	GroupCode    string     `json:"group_code"`
+	Status       string     `json:"status"`
+	Attempts     int        `json:"attempts"`
+	LastError    *string    `json:"last_error"`
+	HasOptimized bool       `json:"has_optimized"`
+	FinishedAt   *time.Time `json:"finished_at"`
+}
+
+type SignedURLResponse struct {
+	Url       string    `json:"url"`
+	Size      string    `json:"size"`
+	ExpiresAt time.Time `json:"expires_at"`
+}
+
+const maxSignedURLTTL = 7 * 24 * time.Hour
+
+// SignedURL godoc
+// @Summary Create a signed URL for a gallery item
+// @Description Create a temporary signed URL to download a (private) gallery item
+// @Tags galleries
+// @Accept json
+// @Produce json
+// @Param id path int true "Gallery ID"
+// @Param expires_in query int false "Lifetime of the URL in seconds (max 604800)" default(3600)
+// @Param w query int false "Transform: width in pixels"
+// @Param h query int false "Transform: height in pixels"
+// @Param fit query string false "Transform: fit mode (cover, contain, fill)"
+// @Param q query int false "Transform: quality (1-100)"
+// @Param fm query string false "Transform: output format (jpeg, png, webp, avif, auto)"
+// @Success 200 {object} utils.Response{data=SignedURLResponse}
+// @Failure 400 {object} utils.SimpleErrorResponse
+// @Failure 404 {object} utils.SimpleErrorResponse
+// @Failure 403 {object} utils.SimpleErrorResponse
+// @Router /galleries/{id}/signed-url [get]
+// @Security BearerAuth
+func (ctrl *GalleryController) SignedURL(c *fiber.Ctx) error {
+	id, err := strconv.Atoi(c.Params("id"))
+	if err != nil {
+		return utils.ErrorResponse(c, fiber.StatusBadRequest, "Invalid gallery ID")
+	}
+
+	ttl, err := parseSignedURLTTL(c)
+	if err != nil {
+		return utils.ErrorResponse(c, fiber.StatusBadRequest, err.Error())
+	}
+
+	gallery, err := ctrl.galleries(c).FindByID(uint64(id), false)
+	if err != nil {
+		return utils.ErrorResponse(c, fiber.StatusNotFound, "Gallery not found")
+	}
+
+	return ctrl.signedURLResponse(c, gallery.GroupCode, gallery.Size, gallery.Format, ttl)
+}
+
+// SignedURLByGroupCode godoc
+// @Summary Create a signed URL by group code
+// @Description Create a temporary signed URL to download a (private) gallery item by group code
+// @Tags galleries
+// @Accept json
+// @Produce json
+// @Param group_code path string true "Group Code"
+// @Param size query string false "Size (original, small, medium, large)" default(original)
+// @Param format query string false "Format (jpeg, png, webp, avif), negotiated from the Accept header when empty"
+// @Param expires_in query int false "Lifetime of the URL in seconds (max 604800)" default(3600)
+// @Param w query int false "Transform: width in pixels"
+// @Param h query int false "Transform: height in pixels"
+// @Param fit query string false "Transform: fit mode (cover, contain, fill)"
+// @Param q query int false "Transform: quality (1-100)"
+// @Param fm query string false "Transform: output format (jpeg, png, webp, avif, auto)"
+// @Success 200 {object} utils.Response{data=SignedURLResponse}
+// @Failure 400 {object} utils.SimpleErrorResponse
+// @Failure 404 {object} utils.SimpleErrorResponse
+// @Failure 403 {object} utils.SimpleErrorResponse
+// @Router /galleries/{group_code}/signed-url [get]
+// @Security BearerAuth
+func (ctrl *GalleryController) SignedURLByGroupCode(c *fiber.Ctx) error {
+	groupCode := c.Params("group_code")
+	size := c.Query("size", "original")
+	format := c.Query("format", "")
+
+	ttl, err := parseSignedURLTTL(c)
+	if err != nil {
+		return utils.ErrorResponse(c, fiber.StatusBadRequest, err.Error())
+	}
+
+	galleries, err := ctrl.galleries(c).FindByGroupCode(groupCode, size)
+	if err != nil || len(galleries) < 1 {
+		return utils.ErrorResponse(c, fiber.StatusNotFound, "Gallery not found")
+	}
+
+	return ctrl.signedURLResponse(c, groupCode, size, format, ttl)
+}
+
+// signedURLResponse signs a plain download URL, or a transformation URL of the
+// group's original when any of the w, h, fit, q or fm parameters is given.
+func (ctrl *GalleryController) signedURLResponse(c *fiber.Ctx, groupCode, size, format string, ttl time.Duration) error {
+	var url string
+	var expiresAt time.Time
+
+	if hasTransformParams(c) {
+		opts, err := parseTransformOptions(c)
+		if err != nil {
+			return utils.ErrorResponse(c, fiber.StatusBadRequest, err.Error())
+		}
+
+		size = "original"
+		url, expiresAt = signing.TransformURL(groupCode, opts.Canonical(), ttl)
+	} else {
+		url, expiresAt = signing.URL(groupCode, size, format, ttl)
+	}
+
+	return utils.SuccessResponse(c, "Signed URL created successfully", SignedURLResponse{
+		Url:       url,
+		Size:      size,
+		ExpiresAt: expiresAt,
+	})
+}
+
+func hasTransformParams(c *fiber.Ctx) bool {
+	for _, key := range []string{"w", "h", "fit", "q", "fm"} {
+		if c.Query(key, "") != "" {
+			return true
+		}
+	}
+	return false
+}
+
+func parseSignedURLTTL(c *fiber.Ctx) (time.Duration, error) {
+	expiresIn := c.Query("expires_in", "")
+	if expiresIn == "" {
+		return config.SignedURLTTL, nil
+	}
+
+	seconds, err := strconv.Atoi(expiresIn)
+	if err != nil || seconds < 1 {
+		return 0, fmt.Errorf("invalid expires_in value")
+	}
+
+	ttl := time.Duration(seconds) * time.Second
+	if ttl > maxSignedURLTTL {
+		return 0, fmt.Errorf("expires_in must not exceed %d seconds", int(maxSignedURLTTL.Seconds()))
+	}
+
+	return ttl, nil
+}
@@ -0,0 +1,134 @@
+package controllers
+
+import (
+	"errors"
+	"fmt"
+	"mime"
+	"nova-cdn/internal/models"
+	"nova-cdn/internal/repositories"
+	"nova-cdn/internal/signing"
+	"nova-cdn/internal/storage"
+	"nova-cdn/pkg/utils"
+	"path"
+	"slices"
+	"strconv"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	"gorm.io/gorm"
+)
+
+type PrivateFileController struct {
+	GalleryRepo *repositories.GalleryRepository
+	Storage     *storage.Disks
+}
+
+func NewPrivateFileController(db *gorm.DB) *PrivateFileController {
+	return &PrivateFileController{
+		GalleryRepo: repositories.NewGalleryRepository(db),
+		Storage:     storage.GetStorage(),
+	}
+}
+
+// Show serves a gallery file once the signature and expiry of the URL have
+// been verified. URLs are minted by GalleryController.SignedURL.
+func (ctrl *PrivateFileController) Show(c *fiber.Ctx) error {
+	groupCode := c.Params("group_code")
+	size := c.Query("size", "")
+	format := c.Query("format", "")
+	signature := c.Query("signature", "")
+
+	expires, err := strconv.ParseInt(c.Query("expires", ""), 10, 64)
+	if err != nil || signature == "" {
+		return utils.ErrorResponse(c, fiber.StatusForbidden, "Invalid signature")
+	}
+
+	if !signing.Verify(groupCode, size, format, expires, signature) {
+		return utils.ErrorResponse(c, fiber.StatusForbidden, "Invalid signature")
+	}
+
+	expiresAt := time.Unix(expires, 0)
+	if time.Now().After(expiresAt) {
+		return utils.ErrorResponse(c, fiber.StatusForbidden, "Signed URL has expired")
+	}
+
+	if size == "" {
+		size = "original"
+	}
+
+	galleries, err := ctrl.GalleryRepo.FindByGroupCode(groupCode, size)
+	if err != nil || len(galleries) < 1 {
+		return utils.ErrorResponse(c, fiber.StatusNotFound, "File not found")
+	}
+
+	gallery, ok := pickGalleryFormat(galleries, format, c.Get(fiber.HeaderAccept))
+	if !ok {
+		return utils.ErrorResponse(c, fiber.StatusNotFound, "File not found")
+	}
+
+	if format == "" && len(galleries) > 1 {
+		c.Vary(fiber.HeaderAccept)
+	}
+
+	disk := ctrl.Storage.For(gallery.IsPrivate)
+
+	info, err := disk.Stat(gallery.FilePath)
+	if errors.Is(err, storage.ErrNotFound) {
+		return utils.ErrorResponse(c, fiber.StatusNotFound, "File not found")
+	}
+	if err != nil {
+		return utils.ErrorResponse(c, fiber.StatusInternalServerError, "Failed to read file")
+	}
+
+	file, err := disk.Get(gallery.FilePath)
+	if err != nil {
+		return utils.ErrorResponse(c, fiber.StatusInternalServerError, "Failed to read file")
+	}
+
+	contentType := gallery.MimeType
+	if contentType == "" {
+		contentType = mime.TypeByExtension(path.Ext(gallery.FilePath))
+	}
+	if contentType == "" {
+		contentType = fiber.MIMEOctetStream
+	}
+
+	maxAge := int(time.Until(expiresAt).Seconds())
+
+	etag := ""
+	if gallery.Checksum != "" {
+		etag = fmt.Sprintf("%q", gallery.Checksum)
+	}
+
+	c.Set(fiber.HeaderCacheControl, fmt.Sprintf("private, max-age=%d", maxAge))
+	c.Set(fiber.HeaderContentDisposition, fmt.Sprintf("inline; filename=%q", gallery.FileName))
+
+	return serveContent(c, file, info.Size, contentType, etag, info.ModTime)
+}
+
+// pickGalleryFormat returns the row with the requested format, or the best
+// format for the Accept header among rows sharing the same group code and size.
+func pickGalleryFormat(galleries []models.Gallery, format, accept string) (models.Gallery, bool) {
+	byFormat := map[string]models.Gallery{}
+	available := []string{}
+
+	for _, gallery := range galleries {
+		byFormat[gallery.Format] = gallery
+		available = append(available, gallery.Format)
+	}
+
+	if format != "" {
+		gallery, ok := byFormat[format]
+		return gallery, ok
+	}
+
+	fallback := galleries[0]
+	for _, gallery := range galleries {
+		if !slices.Contains(utils.ModernFormats, gallery.Format) {
+			fallback = gallery
+			break
+		}
+	}
+
+	return byFormat[utils.NegotiateImageFormat(accept, available, fallback.Format)], true
+}
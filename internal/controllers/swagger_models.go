@@ -9,12 +9,26 @@ type GallerySwagger struct {
 	FilePath     string    `json:"file_path"`
 	Url          string    `json:"url"`
 	FileSize     uint32    `json:"file_size"`
+	Checksum     string    `json:"checksum"`
+	Width        uint      `json:"width"`
+	Height       uint      `json:"height"`
+	Duration     *float64  `json:"duration"`
+	Codec        *string   `json:"codec"`
+	Bitrate      *uint64   `json:"bitrate"`
 	IsPrivate    bool      `json:"is_private"`
 	Description  string    `json:"description"`
 	Size         string    `json:"size"`
+	Format       string    `json:"format"`
+	MimeType     string    `json:"mime_type"`
 	HasOptimized bool      `json:"has_optimized"`
 	GroupCode    string    `json:"group_code"`
+	BrokenAt     *string   `json:"broken_at"`
 	CreatedAt    time.Time `json:"created_at"`
 	UpdatedAt    time.Time `json:"updated_at"`
 	DeletedAt    *string   `json:"deleted_at"`
 }
+
+type TrashedGallerySwagger struct {
+	GallerySwagger
+	PurgeAt *string `json:"purge_at"`
+}
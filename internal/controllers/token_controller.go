@@ -0,0 +1,213 @@
+package controllers
+
+import (
+	"nova-cdn/internal/models"
+	"nova-cdn/internal/repositories"
+	"nova-cdn/pkg/utils"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/thedevsaddam/govalidator"
+	"gorm.io/gorm"
+)
+
+type TokenController struct {
+	TokenRepo *repositories.PersonalAccessTokenRepository
+}
+
+func NewTokenController(db *gorm.DB) *TokenController {
+	return &TokenController{TokenRepo: repositories.NewPersonalAccessTokenRepository(db)}
+}
+
+type CreateTokenRequest struct {
+	Name      string   `json:"name" example:"ocr-server"`
+	Abilities []string `json:"abilities" example:"gallery:write"`
+	ExpiresAt string   `json:"expires_at" example:"2027-01-01T00:00:00Z"`
+}
+
+type TokenResponse struct {
+	ID         uint       `json:"id"`
+	Name       string     `json:"name"`
+	Abilities  []string   `json:"abilities"`
+	Current    bool       `json:"current"`
+	LastUsedAt *time.Time `json:"last_used_at"`
+	ExpiresAt  *time.Time `json:"expires_at"`
+	CreatedAt  time.Time  `json:"created_at"`
+}
+
+type NewTokenResponse struct {
+	TokenResponse
+	PlainTextToken string `json:"plain_text_token"`
+}
+
+// Index godoc
+// @Summary List personal access tokens
+// @Description List the tokens of the authenticated user. Token values are never returned.
+// @Tags auth
+// @Produce json
+// @Success 200 {object} utils.Response{data=[]TokenResponse}
+// @Failure 401 {object} utils.UnauthorizedResponse
+// @Failure 403 {object} utils.SimpleErrorResponse
+// @Router /auth/tokens [get]
+// @Security BearerAuth
+func (ctrl *TokenController) Index(c *fiber.Ctx) error {
+	current := c.Locals("token").(models.PersonalAccessToken)
+
+	tokens, err := ctrl.TokenRepo.FindByUserID(current.TokenableID)
+	if err != nil {
+		return utils.ErrorResponse(c, fiber.StatusInternalServerError, "Failed to retrieve tokens")
+	}
+
+	result := make([]TokenResponse, 0, len(tokens))
+	for _, token := range tokens {
+		result = append(result, newTokenResponse(&token, current.ID))
+	}
+
+	return utils.SuccessResponse(c, "Tokens retrieved successfully", result)
+}
+
+// Store godoc
+// @Summary Create a personal access token
+// @Description Create a named token, optionally restricted to some abilities and with an expiry. Abilities can't exceed the ones of the calling token, and the plain text token is only returned in this response.
+// @Tags auth
+// @Accept json
+// @Produce json
+// @Param token body CreateTokenRequest true "Token options"
+// @Success 201 {object} utils.Response{data=NewTokenResponse}
+// @Failure 401 {object} utils.UnauthorizedResponse
+// @Failure 403 {object} utils.SimpleErrorResponse
+// @Failure 422 {object} utils.ValidationErrorResponse
+// @Router /auth/tokens [post]
+// @Security BearerAuth
+func (ctrl *TokenController) Store(c *fiber.Ctx) error {
+	current := c.Locals("token").(models.PersonalAccessToken)
+	data := make(map[string]interface{})
+
+	rules := govalidator.MapData{
+		"name": []string{"required", "max:255"},
+	}
+
+	errs := utils.ValidateJSON(c, &data, rules)
+	if errs != nil {
+		return utils.ValidationError(c, errs)
+	}
+
+	abilities, errs := parseAbilities(data["abilities"])
+	if errs != nil {
+		return utils.ValidationError(c, errs)
+	}
+
+	for _, ability := range abilities {
+		if !canGrant(current, ability) {
+			return utils.ErrorResponse(c, fiber.StatusForbidden, "Forbidden: Token can't grant the "+ability+" ability")
+		}
+	}
+
+	var expiresAt *time.Time
+	if value, ok := data["expires_at"].(string); ok && value != "" {
+		parsed, err := time.Parse(time.RFC3339, value)
+		if err != nil || !parsed.After(time.Now()) {
+			return utils.ValidationError(c, map[string][]string{"expires_at": {"The expires_at field must be a future RFC 3339 date"}})
+		}
+		expiresAt = &parsed
+	}
+
+	plainTextToken, token, err := issueToken(ctrl.TokenRepo, current.TokenableID, data["name"].(string), abilities, expiresAt)
+	if err != nil {
+		return utils.ErrorResponse(c, fiber.StatusInternalServerError, "Failed to create token")
+	}
+
+	return utils.CreatedResponse(c, "Token created successfully, it won't be shown again", NewTokenResponse{
+		TokenResponse:  newTokenResponse(token, current.ID),
+		PlainTextToken: plainTextToken,
+	})
+}
+
+// Destroy godoc
+// @Summary Revoke a personal access token
+// @Description Revoke one of the tokens of the authenticated user
+// @Tags auth
+// @Produce json
+// @Param id path int true "Token ID"
+// @Success 200 {object} utils.SimpleResponse
+// @Failure 401 {object} utils.UnauthorizedResponse
+// @Failure 403 {object} utils.SimpleErrorResponse
+// @Failure 404 {object} utils.SimpleErrorResponse
+// @Router /auth/tokens/{id} [delete]
+// @Security BearerAuth
+func (ctrl *TokenController) Destroy(c *fiber.Ctx) error {
+	current := c.Locals("token").(models.PersonalAccessToken)
+
+	id, err := strconv.ParseUint(c.Params("id"), 10, 64)
+	if err != nil {
+		return utils.ErrorResponse(c, fiber.StatusBadRequest, "Invalid token ID")
+	}
+
+	token, err := ctrl.TokenRepo.FindByIDAndUserID(id, current.TokenableID)
+	if err != nil {
+		return utils.ErrorResponse(c, fiber.StatusNotFound, "Token not found")
+	}
+
+	if err := ctrl.TokenRepo.Delete(token); err != nil {
+		return utils.ErrorResponse(c, fiber.StatusInternalServerError, "Failed to revoke token")
+	}
+
+	return utils.SimpleSuccessResponse(c, "Token revoked successfully")
+}
+
+// DestroyAll godoc
+// @Summary Revoke all personal access tokens
+// @Description Revoke every token of the authenticated user, including the one used for this request
+// @Tags auth
+// @Produce json
+// @Success 200 {object} utils.SimpleResponse
+// @Failure 401 {object} utils.UnauthorizedResponse
+// @Failure 403 {object} utils.SimpleErrorResponse
+// @Router /auth/tokens [delete]
+// @Security BearerAuth
+func (ctrl *TokenController) DestroyAll(c *fiber.Ctx) error {
+	current := c.Locals("token").(models.PersonalAccessToken)
+
+	if err := ctrl.TokenRepo.DeleteByUserID(current.TokenableID); err != nil {
+		return utils.ErrorResponse(c, fiber.StatusInternalServerError, "Failed to revoke tokens")
+	}
+
+	return utils.SimpleSuccessResponse(c, "All tokens revoked successfully")
+}
+
+// canGrant keeps a restricted token from minting a more powerful one.
+func canGrant(current models.PersonalAccessToken, ability string) bool {
+	if ability == models.AbilityAll {
+		for _, granted := range current.AbilityList() {
+			if granted == models.AbilityAll {
+				return true
+			}
+		}
+		return false
+	}
+
+	if resource, action, _ := strings.Cut(ability, ":"); action == "*" {
+		for _, known := range models.Abilities {
+			if strings.HasPrefix(known, resource+":") && !current.Can(known) {
+				return false
+			}
+		}
+		return true
+	}
+
+	return current.Can(ability)
+}
+
+func newTokenResponse(token *models.PersonalAccessToken, currentID uint) TokenResponse {
+	return TokenResponse{
+		ID:         token.ID,
+		Name:       token.Name,
+		Abilities:  token.AbilityList(),
+		Current:    token.ID == currentID,
+		LastUsedAt: token.LastUsedAt,
+		ExpiresAt:  token.ExpiresAt,
+		CreatedAt:  token.CreatedAt,
+	}
+}
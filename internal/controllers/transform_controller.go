@@ -0,0 +1,195 @@
+package controllers
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"log"
+	"nova-cdn/internal/config"
+	"nova-cdn/internal/imagecache"
+	"nova-cdn/internal/repositories"
+	"nova-cdn/internal/signing"
+	"nova-cdn/internal/storage"
+	"nova-cdn/pkg/utils"
+	"slices"
+	"strconv"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	"gorm.io/gorm"
+)
+
+type TransformController struct {
+	GalleryRepo *repositories.GalleryRepository
+	Storage     *storage.Disks
+	Cache       *imagecache.Cache
+}
+
+func NewTransformController(db *gorm.DB) *TransformController {
+	cache, err := imagecache.New(config.TransformCacheDir, config.TransformCacheMaxSize)
+	if err != nil {
+		log.Fatal("Failed to initialize transform cache:", err)
+	}
+
+	return &TransformController{
+		GalleryRepo: repositories.NewGalleryRepository(db),
+		Storage:     storage.GetStorage(),
+		Cache:       cache,
+	}
+}
+
+// Show renders the original image of a group with the requested size, fit,
+// quality and format. Derivatives are cached on disk, so only parameters from
+// the configured whitelist are accepted unless the URL carries a signature.
+func (ctrl *TransformController) Show(c *fiber.Ctx) error {
+	groupCode := c.Params("group_code")
+
+	opts, err := parseTransformOptions(c)
+	if err != nil {
+		return utils.ErrorResponse(c, fiber.StatusBadRequest, err.Error())
+	}
+
+	signed := false
+	signature := c.Query("signature", "")
+	canonical := opts.Canonical()
+
+	if signature != "" {
+		expires, _ := strconv.ParseInt(c.Query("expires", "0"), 10, 64)
+
+		if !signing.VerifyTransform(groupCode, canonical, expires, signature) {
+			return utils.ErrorResponse(c, fiber.StatusForbidden, "Invalid signature")
+		}
+
+		if expires > 0 && time.Now().Unix() > expires {
+			return utils.ErrorResponse(c, fiber.StatusForbidden, "Signed URL has expired")
+		}
+
+		signed = true
+	}
+
+	if !signed && !isWhitelistedTransform(opts) {
+		return utils.ErrorResponse(c, fiber.StatusBadRequest, "Transformation parameters are not allowed")
+	}
+
+	galleries, err := ctrl.GalleryRepo.FindByGroupCode(groupCode, "original")
+	if err != nil || len(galleries) < 1 {
+		return utils.ErrorResponse(c, fiber.StatusNotFound, "Image not found")
+	}
+
+	original := galleries[0]
+	if _, isImage := utils.ImageExtensions[original.MimeType]; !isImage {
+		return utils.ErrorResponse(c, fiber.StatusNotFound, "Image not found")
+	}
+
+	if original.IsPrivate && (!signed || c.Query("expires", "") == "") {
+		return utils.ErrorResponse(c, fiber.StatusNotFound, "Image not found")
+	}
+
+	if opts.Format == "auto" {
+		opts.Format = utils.NegotiateImageFormat(c.Get(fiber.HeaderAccept), availableModernFormats(), "jpeg")
+		c.Vary(fiber.HeaderAccept)
+	}
+
+	cacheControl := "public, max-age=31536000, immutable"
+	if original.IsPrivate {
+		cacheControl = "private, max-age=3600"
+	}
+
+	key := transformCacheKey(groupCode, opts)
+
+	if data, ok := ctrl.Cache.Get(key); ok {
+		c.Set(fiber.HeaderContentType, utils.ImageFormats[opts.Format].MimeType)
+		c.Set(fiber.HeaderCacheControl, cacheControl)
+		c.Set("X-Cache", "HIT")
+		return c.Send(data)
+	}
+
+	file, err := ctrl.Storage.For(original.IsPrivate).Get(original.FilePath)
+	if err != nil {
+		return utils.ErrorResponse(c, fiber.StatusNotFound, "Image not found")
+	}
+	defer file.Close()
+
+	data, _, err := utils.TransformImage(file, opts)
+	if err != nil {
+		return utils.ErrorResponse(c, fiber.StatusUnprocessableEntity, "Failed to transform image")
+	}
+
+	if err := ctrl.Cache.Put(key, data); err != nil {
+		log.Printf("Failed to cache transformed image %s: %v\n", key, err)
+	}
+
+	c.Set(fiber.HeaderContentType, utils.ImageFormats[opts.Format].MimeType)
+	c.Set(fiber.HeaderCacheControl, cacheControl)
+	c.Set("X-Cache", "MISS")
+	return c.Send(data)
+}
+
+func parseTransformOptions(c *fiber.Ctx) (utils.TransformOptions, error) {
+	opts := utils.TransformOptions{
+		Fit:     c.Query("fit", utils.DefaultTransformFit),
+		Quality: utils.DefaultTransformQuality,
+		Format:  c.Query("fm", "jpeg"),
+	}
+
+	var err error
+
+	if w := c.Query("w", ""); w != "" {
+		if opts.Width, err = strconv.Atoi(w); err != nil || opts.Width < 1 || opts.Width > utils.MaxTransformDimension {
+			return opts, fmt.Errorf("w must be between 1 and %d", utils.MaxTransformDimension)
+		}
+	}
+
+	if h := c.Query("h", ""); h != "" {
+		if opts.Height, err = strconv.Atoi(h); err != nil || opts.Height < 1 || opts.Height > utils.MaxTransformDimension {
+			return opts, fmt.Errorf("h must be between 1 and %d", utils.MaxTransformDimension)
+		}
+	}
+
+	if q := c.Query("q", ""); q != "" {
+		if opts.Quality, err = strconv.Atoi(q); err != nil || opts.Quality < 1 || opts.Quality > 100 {
+			return opts, fmt.Errorf("q must be between 1 and 100")
+		}
+	}
+
+	if !utils.TransformFits[opts.Fit] {
+		return opts, fmt.Errorf("fit must be one of cover, contain or fill")
+	}
+
+	if opts.Format != "auto" && !utils.IsFormatAvailable(opts.Format) {
+		return opts, fmt.Errorf("unsupported output format: %s", opts.Format)
+	}
+
+	return opts, nil
+}
+
+func availableModernFormats() []string {
+	var formats []string
+	for _, format := range utils.ModernFormats {
+		if utils.IsFormatAvailable(format) {
+			formats = append(formats, format)
+		}
+	}
+	return formats
+}
+
+func isWhitelistedTransform(opts utils.TransformOptions) bool {
+	if opts.Width != 0 && !slices.Contains(config.TransformAllowedWidths, opts.Width) {
+		return false
+	}
+
+	if opts.Height != 0 && !slices.Contains(config.TransformAllowedHeights, opts.Height) {
+		return false
+	}
+
+	if opts.Quality != utils.DefaultTransformQuality && !slices.Contains(config.TransformAllowedQuality, opts.Quality) {
+		return false
+	}
+
+	return true
+}
+
+func transformCacheKey(groupCode string, opts utils.TransformOptions) string {
+	hash := sha256.Sum256([]byte(groupCode + "?" + opts.Canonical()))
+	return hex.EncodeToString(hash[:]) + "." + opts.Format
+}
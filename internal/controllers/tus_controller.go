@@ -0,0 +1,342 @@
+package controllers
+
+import (
+	"bytes"
+	"encoding/base64"
+	"errors"
+	"log"
+	"net/http"
+	"nova-cdn/internal/config"
+	"nova-cdn/internal/dto"
+	"nova-cdn/internal/service"
+	"nova-cdn/internal/tus"
+	"nova-cdn/pkg/utils"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	"gorm.io/gorm"
+)
+
+const (
+	tusVersion    = "1.0.0"
+	tusExtensions = "creation,creation-with-upload,expiration,termination"
+)
+
+// TusController implements the core tus 1.0.0 protocol plus the creation,
+// expiration and termination extensions. Completed uploads are handed to
+// GalleryService.Store, the same pipeline used by multipart uploads.
+type TusController struct {
+	Store          *tus.FileStore
+	GalleryService service.GalleryService
+}
+
+func NewTusController(db *gorm.DB) *TusController {
+	store, err := tus.NewFileStore(config.TusUploadDir, config.TusUploadExpiration)
+	if err != nil {
+		log.Fatal("Failed to initialize upload store:", err)
+	}
+
+	go store.RunGarbageCollector(time.Hour)
+
+	return &TusController{
+		Store:          store,
+		GalleryService: service.NewGalleryService(db),
+	}
+}
+
+// Options godoc
+// @Summary Discover resumable upload capabilities
+// @Description tus protocol discovery, lists the supported version, extensions and maximum size
+// @Tags galleries
+// @Success 204
+// @Router /galleries/tus [options]
+// @Security BearerAuth
+func (ctrl *TusController) Options(c *fiber.Ctx) error {
+	c.Set("Tus-Resumable", tusVersion)
+	c.Set("Tus-Version", tusVersion)
+	c.Set("Tus-Extension", tusExtensions)
+	c.Set("Tus-Max-Size", strconv.FormatInt(config.TusMaxSize, 10))
+	return c.SendStatus(fiber.StatusNoContent)
+}
+
+// Create godoc
+// @Summary Create a resumable upload
+// @Description Create a tus upload. Supported Upload-Metadata keys: filename, filetype, dir, description, is_private, subject_id, subject_type
+// @Tags galleries
+// @Param Tus-Resumable header string true "Protocol version" default(1.0.0)
+// @Param Upload-Length header int true "Total size of the file in bytes"
+// @Param Upload-Metadata header string false "Comma separated key and base64 value pairs"
+// @Success 201
+// @Failure 400 {object} utils.SimpleErrorResponse
+// @Failure 413 {object} utils.SimpleErrorResponse
+// @Router /galleries/tus [post]
+// @Security BearerAuth
+func (ctrl *TusController) Create(c *fiber.Ctx) error {
+	if err := checkTusResumable(c); err != nil {
+		return err
+	}
+
+	length, err := strconv.ParseInt(c.Get("Upload-Length"), 10, 64)
+	if err != nil || length < 1 {
+		return utils.ErrorResponse(c, fiber.StatusBadRequest, "Invalid Upload-Length header")
+	}
+
+	if length > config.TusMaxSize {
+		return utils.ErrorResponse(c, fiber.StatusRequestEntityTooLarge, "Upload exceeds the maximum size")
+	}
+
+	metadata, err := parseTusMetadata(c.Get("Upload-Metadata"))
+	if err != nil {
+		return utils.ErrorResponse(c, fiber.StatusBadRequest, "Invalid Upload-Metadata header")
+	}
+
+	userID := c.Locals("user_id").(uint)
+
+	// Validate the metadata up front instead of after the whole file arrived.
+	if _, err := ctrl.GalleryService.ParseUploadInput(userID, metadataLookup(metadata)); err != nil {
+		return utils.ErrorResponse(c, fiber.StatusBadRequest, err.Error())
+	}
+
+	upload, err := ctrl.Store.Create(userID, length, metadata)
+	if err != nil {
+		return utils.ErrorResponse(c, fiber.StatusInternalServerError, "Failed to create upload")
+	}
+
+	c.Set("Tus-Resumable", tusVersion)
+	baseURL := config.AppURL
+	if baseURL == "" {
+		baseURL = c.BaseURL()
+	}
+
+	c.Set(fiber.HeaderLocation, baseURL+"/api/galleries/tus/"+upload.ID)
+	c.Set("Upload-Expires", upload.ExpiresAt.UTC().Format(http.TimeFormat))
+
+	if len(c.Body()) > 0 && c.Get(fiber.HeaderContentType) == "application/offset+octet-stream" {
+		return ctrl.appendChunk(c, upload, 0, fiber.StatusCreated)
+	}
+
+	c.Set("Upload-Offset", "0")
+	return c.SendStatus(fiber.StatusCreated)
+}
+
+// Head godoc
+// @Summary Get the offset of a resumable upload
+// @Description Returns Upload-Offset and Upload-Length so the client can resume, plus Upload-Group-Code once completed
+// @Tags galleries
+// @Param id path string true "Upload ID"
+// @Success 200
+// @Failure 404
+// @Router /galleries/tus/{id} [head]
+// @Security BearerAuth
+func (ctrl *TusController) Head(c *fiber.Ctx) error {
+	upload, err := ctrl.findUpload(c)
+	if err != nil {
+		return c.SendStatus(fiber.StatusNotFound)
+	}
+
+	c.Set("Tus-Resumable", tusVersion)
+	c.Set(fiber.HeaderCacheControl, "no-store")
+	c.Set("Upload-Offset", strconv.FormatInt(upload.Offset, 10))
+	c.Set("Upload-Length", strconv.FormatInt(upload.Length, 10))
+	c.Set("Upload-Expires", upload.ExpiresAt.UTC().Format(http.TimeFormat))
+
+	if upload.GroupCode != "" {
+		c.Set("Upload-Group-Code", upload.GroupCode)
+	}
+
+	return c.SendStatus(fiber.StatusOK)
+}
+
+// Patch godoc
+// @Summary Upload a chunk of a resumable upload
+// @Description Appends the request body at Upload-Offset. The last chunk stores the file and responds with Upload-Group-Code
+// @Tags galleries
+// @Accept application/offset+octet-stream
+// @Param id path string true "Upload ID"
+// @Param Tus-Resumable header string true "Protocol version" default(1.0.0)
+// @Param Upload-Offset header int true "Offset of the chunk"
+// @Success 204
+// @Failure 404
+// @Failure 409 {object} utils.SimpleErrorResponse
+// @Failure 415 {object} utils.SimpleErrorResponse
+// @Router /galleries/tus/{id} [patch]
+// @Security BearerAuth
+func (ctrl *TusController) Patch(c *fiber.Ctx) error {
+	if err := checkTusResumable(c); err != nil {
+		return err
+	}
+
+	if c.Get(fiber.HeaderContentType) != "application/offset+octet-stream" {
+		return utils.ErrorResponse(c, fiber.StatusUnsupportedMediaType, "Content-Type must be application/offset+octet-stream")
+	}
+
+	offset, err := strconv.ParseInt(c.Get("Upload-Offset"), 10, 64)
+	if err != nil || offset < 0 {
+		return utils.ErrorResponse(c, fiber.StatusBadRequest, "Invalid Upload-Offset header")
+	}
+
+	upload, err := ctrl.findUpload(c)
+	if err != nil {
+		return c.SendStatus(fiber.StatusNotFound)
+	}
+
+	if upload.GroupCode != "" {
+		return utils.ErrorResponse(c, fiber.StatusConflict, "Upload is already completed")
+	}
+
+	return ctrl.appendChunk(c, upload, offset, fiber.StatusNoContent)
+}
+
+// Delete godoc
+// @Summary Terminate a resumable upload
+// @Description Discards an unfinished upload and its chunks
+// @Tags galleries
+// @Param id path string true "Upload ID"
+// @Success 204
+// @Failure 404
+// @Router /galleries/tus/{id} [delete]
+// @Security BearerAuth
+func (ctrl *TusController) Delete(c *fiber.Ctx) error {
+	upload, err := ctrl.findUpload(c)
+	if err != nil {
+		return c.SendStatus(fiber.StatusNotFound)
+	}
+
+	if err := ctrl.Store.Delete(upload.ID); err != nil {
+		return c.SendStatus(fiber.StatusNotFound)
+	}
+
+	c.Set("Tus-Resumable", tusVersion)
+	return c.SendStatus(fiber.StatusNoContent)
+}
+
+func (ctrl *TusController) appendChunk(c *fiber.Ctx, upload *tus.Upload, offset int64, status int) error {
+	id := upload.ID
+	upload, err := ctrl.Store.Append(id, offset, bytes.NewReader(c.Body()), ctrl.finish)
+
+	c.Set("Tus-Resumable", tusVersion)
+
+	if e, ok := err.(*fiber.Error); ok {
+		// A rejected file is dropped, the client may retry the finish when
+		// the server is at fault.
+		if e.Code < fiber.StatusInternalServerError {
+			ctrl.Store.Delete(id)
+		}
+		return utils.ErrorResponse(c, e.Code, e.Message)
+	}
+
+	switch {
+	case errors.Is(err, tus.ErrNotFound):
+		return c.SendStatus(fiber.StatusNotFound)
+	case errors.Is(err, tus.ErrCompleted):
+		return utils.ErrorResponse(c, fiber.StatusConflict, "Upload is already completed")
+	case errors.Is(err, tus.ErrOffsetMismatch):
+		return utils.ErrorResponse(c, fiber.StatusConflict, "Upload-Offset does not match the current offset")
+	case errors.Is(err, tus.ErrTooLarge):
+		return utils.ErrorResponse(c, fiber.StatusRequestEntityTooLarge, "Chunk exceeds the upload length")
+	case err != nil && upload != nil && upload.IsComplete():
+		return utils.ErrorResponse(c, fiber.StatusInternalServerError, err.Error())
+	case err != nil:
+		return utils.ErrorResponse(c, fiber.StatusInternalServerError, "Failed to write chunk")
+	}
+
+	c.Set("Upload-Offset", strconv.FormatInt(upload.Offset, 10))
+	c.Set("Upload-Expires", upload.ExpiresAt.UTC().Format(http.TimeFormat))
+
+	if upload.GroupCode != "" {
+		c.Set("Upload-Group-Code", upload.GroupCode)
+	}
+
+	return c.SendStatus(status)
+}
+
+// finish hands the assembled file to the regular upload pipeline, which
+// accepts every allowed type and not only images. It runs under the upload
+// lock held by FileStore.Append.
+func (ctrl *TusController) finish(upload *tus.Upload) (string, error) {
+	input, err := ctrl.GalleryService.ParseUploadInput(upload.UserID, metadataLookup(upload.Metadata))
+	if err != nil {
+		return "", fiber.NewError(fiber.StatusBadRequest, err.Error())
+	}
+
+	file, err := ctrl.Store.Open(upload.ID)
+	if err != nil {
+		return "", err
+	}
+	defer file.Close()
+
+	galleries, err := ctrl.GalleryService.StoreFile(input, &dto.UploadFile{
+		FileName:    upload.Metadata["filename"],
+		ContentType: upload.Metadata["filetype"],
+		Size:        upload.Length,
+		Reader:      file,
+	})
+	if err != nil {
+		return "", err
+	}
+
+	return galleries[0].GroupCode, nil
+}
+
+func (ctrl *TusController) findUpload(c *fiber.Ctx) (*tus.Upload, error) {
+	upload, err := ctrl.Store.Get(c.Params("id"))
+	if err != nil {
+		return nil, err
+	}
+
+	if upload.UserID != c.Locals("user_id").(uint) || time.Now().After(upload.ExpiresAt) {
+		return nil, tus.ErrNotFound
+	}
+
+	return upload, nil
+}
+
+func checkTusResumable(c *fiber.Ctx) error {
+	if c.Get("Tus-Resumable") != tusVersion {
+		c.Set("Tus-Version", tusVersion)
+		return utils.ErrorResponse(c, fiber.StatusPreconditionFailed, "Unsupported tus version")
+	}
+	return nil
+}
+
+func parseTusMetadata(header string) (map[string]string, error) {
+	metadata := map[string]string{}
+
+	if strings.TrimSpace(header) == "" {
+		return metadata, nil
+	}
+
+	for _, pair := range strings.Split(header, ",") {
+		fields := strings.Fields(pair)
+		if len(fields) == 0 || len(fields) > 2 {
+			return nil, errors.New("invalid metadata pair")
+		}
+
+		value := ""
+		if len(fields) == 2 {
+			decoded, err := base64.StdEncoding.DecodeString(fields[1])
+			if err != nil {
+				return nil, err
+			}
+			value = string(decoded)
+		}
+
+		metadata[fields[0]] = value
+	}
+
+	return metadata, nil
+}
+
+func metadataLookup(metadata map[string]string) func(key string, defaultValue ...string) string {
+	return func(key string, defaultValue ...string) string {
+		if value, ok := metadata[key]; ok && value != "" {
+			return value
+		}
+		if len(defaultValue) > 0 {
+			return defaultValue[0]
+		}
+		return ""
+	}
+}
@@ -1,9 +1,12 @@
 package dto
 
+import "io"
+
 const (
 	MaxUploadSize   = 10 * 1024 * 1024 // 10MB
-	UploadDirBase   = "public"
 	DefaultImageDir = "gallery"
+	ImageRoot       = "images"
+	FileRoot        = "files"
 	ModelPrefix     = "App\\Models\\"
 )
 
@@ -22,3 +25,10 @@ type UploadInput struct {
 	SubjectType *string
 	UserID      uint
 }
+
+type UploadFile struct {
+	FileName    string
+	ContentType string
+	Size        int64
+	Reader      io.ReadSeeker
+}
@@ -0,0 +1,200 @@
+package imagecache
+
+import (
+	"container/list"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Cache keeps derived images on the local disk and evicts the least recently
+// used ones once the total size grows past maxSize bytes.
+type Cache struct {
+	dir     string
+	maxSize int64
+
+	mu    sync.Mutex
+	size  int64
+	order *list.List
+	items map[string]*list.Element
+}
+
+type entry struct {
+	key  string
+	size int64
+}
+
+func New(dir string, maxSize int64) (*Cache, error) {
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return nil, err
+	}
+
+	c := &Cache{
+		dir:     dir,
+		maxSize: maxSize,
+		order:   list.New(),
+		items:   make(map[string]*list.Element),
+	}
+
+	if err := c.load(); err != nil {
+		return nil, err
+	}
+
+	return c, nil
+}
+
+// load rebuilds the LRU order from the files left by a previous run, using the
+// modification time that Get refreshes on every hit.
+func (c *Cache) load() error {
+	type found struct {
+		key     string
+		size    int64
+		modTime time.Time
+	}
+
+	var files []found
+
+	err := filepath.WalkDir(c.dir, func(p string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+
+		if d.IsDir() || strings.HasPrefix(d.Name(), ".") {
+			return nil
+		}
+
+		info, err := d.Info()
+		if err != nil {
+			return err
+		}
+
+		files = append(files, found{key: d.Name(), size: info.Size(), modTime: info.ModTime()})
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	sort.Slice(files, func(i, j int) bool {
+		return files[i].modTime.After(files[j].modTime)
+	})
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	for _, f := range files {
+		c.items[f.key] = c.order.PushBack(&entry{key: f.key, size: f.size})
+		c.size += f.size
+	}
+
+	c.evict()
+	return nil
+}
+
+func (c *Cache) Get(key string) ([]byte, bool) {
+	c.mu.Lock()
+	elem, ok := c.items[key]
+	if ok {
+		c.order.MoveToFront(elem)
+	}
+	c.mu.Unlock()
+
+	if !ok {
+		return nil, false
+	}
+
+	data, err := os.ReadFile(c.path(key))
+	if err != nil {
+		c.remove(key)
+		return nil, false
+	}
+
+	now := time.Now()
+	os.Chtimes(c.path(key), now, now)
+
+	return data, true
+}
+
+func (c *Cache) Put(key string, data []byte) error {
+	fullPath := c.path(key)
+
+	if err := os.MkdirAll(filepath.Dir(fullPath), 0755); err != nil {
+		return err
+	}
+
+	tmp, err := os.CreateTemp(filepath.Dir(fullPath), ".cache-*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		return err
+	}
+
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+
+	if err := os.Rename(tmp.Name(), fullPath); err != nil {
+		return err
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if elem, ok := c.items[key]; ok {
+		c.size -= elem.Value.(*entry).size
+		c.order.Remove(elem)
+	}
+
+	c.items[key] = c.order.PushFront(&entry{key: key, size: int64(len(data))})
+	c.size += int64(len(data))
+
+	c.evict()
+	return nil
+}
+
+func (c *Cache) Size() int64 {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.size
+}
+
+func (c *Cache) remove(key string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if elem, ok := c.items[key]; ok {
+		c.size -= elem.Value.(*entry).size
+		c.order.Remove(elem)
+		delete(c.items, key)
+	}
+}
+
+// evict must be called with c.mu held.
+func (c *Cache) evict() {
+	for c.size > c.maxSize && c.order.Len() > 0 {
+		elem := c.order.Back()
+		e := elem.Value.(*entry)
+
+		os.Remove(c.path(e.key))
+
+		c.size -= e.size
+		c.order.Remove(elem)
+		delete(c.items, e.key)
+	}
+}
+
+func (c *Cache) path(key string) string {
+	shard := key
+	if len(shard) > 2 {
+		shard = shard[:2]
+	}
+	return filepath.Join(c.dir, shard, key)
+}
@@ -0,0 +1,32 @@
+package middleware
+
+import (
+	"nova-cdn/internal/models"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// Ability requires the token authenticated by Auth to grant every one of the
+// given abilities. It must run after Auth.
+func Ability(abilities ...string) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		token, ok := c.Locals("token").(models.PersonalAccessToken)
+		if !ok {
+			return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
+				"success": false,
+				"message": "Unauthorized: No token provided",
+			})
+		}
+
+		for _, ability := range abilities {
+			if !token.Can(ability) {
+				return c.Status(fiber.StatusForbidden).JSON(fiber.Map{
+					"success": false,
+					"message": "Forbidden: Token is missing the " + ability + " ability",
+				})
+			}
+		}
+
+		return c.Next()
+	}
+}
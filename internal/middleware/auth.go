@@ -14,6 +14,7 @@ import (
 
 func Auth(db *gorm.DB) fiber.Handler {
 	PersonalAccessTokenRepo := repositories.NewPersonalAccessTokenRepository(db)
+	UserRepo := repositories.NewUserRepository(db)
 
 	return func(c *fiber.Ctx) error {
 		authHeader := c.Get("Authorization")
@@ -72,6 +73,15 @@ func Auth(db *gorm.DB) fiber.Handler {
 			})
 		}
 
+		user, err := UserRepo.FindByID(token.TokenableID)
+
+		if err != nil {
+			return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
+				"success": false,
+				"message": "Unauthorized: Invalid token",
+			})
+		}
+
 		fields := map[string]interface{}{"last_used_at": time.Now()}
 		PersonalAccessTokenRepo.UpdateFields(token, fields)
 
@@ -79,6 +89,7 @@ func Auth(db *gorm.DB) fiber.Handler {
 
 		c.Locals("token", *token)
 		c.Locals("user_id", UserId)
+		c.Locals("is_admin", user.IsAdmin())
 
 		return c.Next()
 	}
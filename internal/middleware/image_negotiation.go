@@ -0,0 +1,51 @@
+package middleware
+
+import (
+	"nova-cdn/internal/storage"
+	"nova-cdn/pkg/utils"
+	"path"
+	"strings"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// ImageNegotiation rewrites requests for JPEG/PNG variants to a sibling AVIF or
+// WebP file when the client accepts it and the file exists on the disk. It has
+// to be registered before the static file handler.
+func ImageNegotiation(disk storage.Storage) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		if c.Method() != fiber.MethodGet && c.Method() != fiber.MethodHead {
+			return c.Next()
+		}
+
+		requestPath := c.Path()
+		ext := strings.ToLower(path.Ext(requestPath))
+
+		if ext != ".jpg" && ext != ".jpeg" && ext != ".png" {
+			return c.Next()
+		}
+
+		c.Vary(fiber.HeaderAccept)
+
+		accept := c.Get(fiber.HeaderAccept)
+		if accept == "" {
+			return c.Next()
+		}
+
+		base := strings.TrimPrefix(strings.TrimSuffix(requestPath, path.Ext(requestPath)), "/")
+
+		var available []string
+		for _, format := range utils.ModernFormats {
+			if _, err := disk.Stat(base + utils.ImageFormats[format].Ext); err == nil {
+				available = append(available, format)
+			}
+		}
+
+		format := utils.NegotiateImageFormat(accept, available, "")
+		if format != "" {
+			c.Request().URI().SetPath("/" + base + utils.ImageFormats[format].Ext)
+		}
+
+		return c.Next()
+	}
+}
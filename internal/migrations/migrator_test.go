@@ -0,0 +1,152 @@
+package migrations
+
+import (
+	"testing"
+
+	"nova-cdn/internal/config"
+	"nova-cdn/internal/models"
+	"nova-cdn/internal/repositories"
+	"nova-cdn/internal/storage"
+
+	"github.com/glebarez/sqlite"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+)
+
+func openTestDB(t *testing.T) *gorm.DB {
+	t.Helper()
+
+	db, err := gorm.Open(sqlite.Open(":memory:"), &gorm.Config{Logger: logger.Default.LogMode(logger.Silent)})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	sqlDB, err := db.DB()
+	if err != nil {
+		t.Fatal(err)
+	}
+	sqlDB.SetMaxOpenConns(1)
+	t.Cleanup(func() { sqlDB.Close() })
+
+	return db
+}
+
+// tables lists the tables of the database besides the version table.
+func tables(t *testing.T, db *gorm.DB) []string {
+	t.Helper()
+
+	var names []string
+	err := db.Raw("SELECT name FROM sqlite_master WHERE type = 'table' AND name NOT LIKE 'sqlite_%' AND name <> ? ORDER BY name", versionTable).
+		Scan(&names).Error
+	if err != nil {
+		t.Fatal(err)
+	}
+	return names
+}
+
+func TestUpAndDown(t *testing.T) {
+	db := openTestDB(t)
+
+	migrator, err := New(db)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	ran, err := migrator.Up()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(ran) != len(migrator.Migrations) {
+		t.Fatalf("applied %d migrations, want %d", len(ran), len(migrator.Migrations))
+	}
+
+	if ran, err := migrator.Up(); err != nil || len(ran) != 0 {
+		t.Fatalf("second run applied %d migrations: %v", len(ran), err)
+	}
+
+	statuses, err := migrator.Status()
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, status := range statuses {
+		if status.AppliedAt == nil {
+			t.Errorf("%d_%s is not applied", status.Version, status.Name)
+		}
+	}
+
+	ran, err = migrator.Down(len(migrator.Migrations))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(ran) != len(migrator.Migrations) {
+		t.Fatalf("rolled back %d migrations, want %d", len(ran), len(migrator.Migrations))
+	}
+	if names := tables(t, db); len(names) > 0 {
+		t.Fatalf("tables left after rolling back: %v", names)
+	}
+
+	// The down scripts leave nothing that would stop a fresh run.
+	if _, err := migrator.Up(); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestSchemaMatchesModels(t *testing.T) {
+	db := openTestDB(t)
+
+	migrator, err := New(db)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := migrator.Up(); err != nil {
+		t.Fatal(err)
+	}
+
+	// Gallery rows build their URL from the configured disks when loaded.
+	config.FilesystemDisk = "local"
+	config.FilesystemRoot = t.TempDir()
+	config.FilesystemPrivateRoot = t.TempDir()
+	storage.ConnectStorage()
+
+	duration, codec, bitrate := 1.5, "h264", uint64(800000)
+	gallery := &models.Gallery{
+		UserID:    1,
+		FileName:  "clip.mp4",
+		FilePath:  "files/gallery/clip.mp4",
+		FileSize:  1024,
+		Checksum:  "abc",
+		Width:     640,
+		Height:    360,
+		Duration:  &duration,
+		Codec:     &codec,
+		Bitrate:   &bitrate,
+		Size:      "original",
+		Format:    "mp4",
+		MimeType:  "video/mp4",
+		GroupCode: "GL-2610000117",
+	}
+
+	repo := repositories.NewGalleryRepository(db)
+	if err := repo.CreateMany([]*models.Gallery{gallery}); err != nil {
+		t.Fatal(err)
+	}
+
+	found, err := repo.FindByID(uint64(gallery.ID), false)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if found.FilePath != gallery.FilePath || found.Checksum != "abc" || found.MimeType != "video/mp4" || found.Width != 640 || found.Height != 360 {
+		t.Errorf("got %+v, want %+v", found, gallery)
+	}
+	if found.Duration == nil || *found.Duration != duration || found.Codec == nil || *found.Codec != codec || found.Bitrate == nil || *found.Bitrate != bitrate {
+		t.Errorf("media info did not round trip: %v %v %v", found.Duration, found.Codec, found.Bitrate)
+	}
+
+	// The unique index only allows one original per group code.
+	duplicate := *gallery
+	duplicate.ID = 0
+	if err := repo.CreateMany([]*models.Gallery{&duplicate}); err == nil {
+		t.Error("a second original with the same group code was created")
+	}
+}
@@ -0,0 +1,18 @@
+CREATE TABLE IF NOT EXISTS generates (
+    id BIGINT UNSIGNED NOT NULL AUTO_INCREMENT,
+    alias VARCHAR(255) NOT NULL,
+    prefix VARCHAR(255) NULL,
+    suffix VARCHAR(255) NULL,
+    queue INT NOT NULL DEFAULT 1,
+    `separator` VARCHAR(255) NOT NULL DEFAULT '',
+    created_at TIMESTAMP NULL,
+    updated_at TIMESTAMP NULL,
+    deleted_at TIMESTAMP NULL,
+    PRIMARY KEY (id),
+    UNIQUE KEY generates_alias_unique (alias)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_unicode_ci;
+
+INSERT INTO generates (alias, prefix, queue, `separator`, created_at, updated_at)
+SELECT 'gallery_group', 'GL-', 1, '', CURRENT_TIMESTAMP, CURRENT_TIMESTAMP
+FROM (SELECT 1) AS seed
+WHERE NOT EXISTS (SELECT 1 FROM generates WHERE alias = 'gallery_group');
@@ -0,0 +1,22 @@
+CREATE TABLE IF NOT EXISTS galleries (
+    id BIGINT UNSIGNED NOT NULL AUTO_INCREMENT,
+    user_id BIGINT UNSIGNED NOT NULL,
+    subject_id BIGINT UNSIGNED NULL,
+    subject_type VARCHAR(255) NULL,
+    file_name VARCHAR(255) NOT NULL,
+    file_path VARCHAR(255) NOT NULL,
+    file_size INT UNSIGNED NOT NULL DEFAULT 0,
+    is_private TINYINT(1) NOT NULL DEFAULT 0,
+    description TEXT NULL,
+    size VARCHAR(20) NOT NULL DEFAULT 'original',
+    has_optimized TINYINT(1) NOT NULL DEFAULT 0,
+    group_code VARCHAR(50) NOT NULL,
+    created_at TIMESTAMP NULL,
+    updated_at TIMESTAMP NULL,
+    deleted_at TIMESTAMP NULL,
+    PRIMARY KEY (id),
+    KEY galleries_user_id_index (user_id),
+    KEY galleries_subject_type_subject_id_index (subject_type, subject_id),
+    KEY galleries_group_code_size_index (group_code, size),
+    KEY galleries_deleted_at_index (deleted_at)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_unicode_ci;
@@ -0,0 +1,5 @@
+ALTER TABLE galleries
+    DROP KEY galleries_file_path_index,
+    DROP KEY galleries_checksum_index,
+    DROP COLUMN checksum,
+    DROP COLUMN format;
@@ -0,0 +1,5 @@
+ALTER TABLE galleries
+    ADD COLUMN format VARCHAR(10) NOT NULL DEFAULT '' AFTER size,
+    ADD COLUMN checksum CHAR(64) NULL AFTER file_size,
+    ADD KEY galleries_checksum_index (checksum),
+    ADD KEY galleries_file_path_index (file_path);
@@ -0,0 +1,17 @@
+CREATE TABLE IF NOT EXISTS image_jobs (
+    id BIGINT UNSIGNED NOT NULL AUTO_INCREMENT,
+    gallery_id BIGINT UNSIGNED NOT NULL,
+    group_code VARCHAR(50) NOT NULL,
+    status VARCHAR(20) NOT NULL DEFAULT 'pending',
+    attempts INT NOT NULL DEFAULT 0,
+    max_attempts INT NOT NULL DEFAULT 5,
+    last_error TEXT NULL,
+    available_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    started_at TIMESTAMP NULL,
+    finished_at TIMESTAMP NULL,
+    created_at TIMESTAMP NULL,
+    updated_at TIMESTAMP NULL,
+    PRIMARY KEY (id),
+    KEY image_jobs_status_available_at_index (status, available_at),
+    KEY image_jobs_group_code_index (group_code)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_unicode_ci;
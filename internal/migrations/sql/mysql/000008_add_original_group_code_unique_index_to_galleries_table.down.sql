@@ -0,0 +1 @@
+DROP INDEX galleries_original_group_code_unique ON galleries;
@@ -0,0 +1 @@
+CREATE UNIQUE INDEX galleries_original_group_code_unique ON galleries ((CASE WHEN size = 'original' THEN group_code END));
@@ -0,0 +1 @@
+ALTER TABLE galleries ADD COLUMN broken_at TIMESTAMP NULL AFTER group_code;
@@ -0,0 +1,5 @@
+ALTER TABLE galleries
+    DROP KEY galleries_file_size_index,
+    DROP KEY galleries_created_at_index,
+    DROP COLUMN height,
+    DROP COLUMN width;
@@ -0,0 +1,5 @@
+ALTER TABLE galleries
+    ADD COLUMN width INT UNSIGNED NOT NULL DEFAULT 0 AFTER checksum,
+    ADD COLUMN height INT UNSIGNED NOT NULL DEFAULT 0 AFTER width,
+    ADD KEY galleries_created_at_index (created_at),
+    ADD KEY galleries_file_size_index (file_size);
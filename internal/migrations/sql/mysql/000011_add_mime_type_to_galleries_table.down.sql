@@ -0,0 +1 @@
+ALTER TABLE galleries DROP KEY galleries_mime_type_index, DROP COLUMN mime_type;
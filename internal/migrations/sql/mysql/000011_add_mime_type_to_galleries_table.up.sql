@@ -0,0 +1,12 @@
+ALTER TABLE galleries
+    ADD COLUMN mime_type VARCHAR(100) NOT NULL DEFAULT '' AFTER format,
+    ADD KEY galleries_mime_type_index (mime_type);
+
+UPDATE galleries SET mime_type = CASE format
+    WHEN 'jpeg' THEN 'image/jpeg'
+    WHEN 'png' THEN 'image/png'
+    WHEN 'gif' THEN 'image/gif'
+    WHEN 'webp' THEN 'image/webp'
+    WHEN 'avif' THEN 'image/avif'
+    ELSE ''
+END;
@@ -0,0 +1,4 @@
+ALTER TABLE galleries
+    DROP COLUMN bitrate,
+    DROP COLUMN codec,
+    DROP COLUMN duration;
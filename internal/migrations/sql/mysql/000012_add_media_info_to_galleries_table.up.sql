@@ -0,0 +1,4 @@
+ALTER TABLE galleries
+    ADD COLUMN duration DOUBLE NULL AFTER height,
+    ADD COLUMN codec VARCHAR(50) NULL AFTER duration,
+    ADD COLUMN bitrate BIGINT UNSIGNED NULL AFTER codec;
@@ -0,0 +1,14 @@
+CREATE TABLE IF NOT EXISTS users (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    name VARCHAR(255) NOT NULL,
+    email VARCHAR(255) NOT NULL,
+    password VARCHAR(255) NOT NULL,
+    has_allow_notification BOOLEAN NULL,
+    notification_token VARCHAR(255) NULL,
+    created_at DATETIME NULL,
+    updated_at DATETIME NULL,
+    deleted_at DATETIME NULL
+);
+
+CREATE UNIQUE INDEX IF NOT EXISTS users_email_unique ON users (email);
+CREATE INDEX IF NOT EXISTS users_deleted_at_index ON users (deleted_at);
@@ -0,0 +1,17 @@
+CREATE TABLE IF NOT EXISTS generates (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    alias VARCHAR(255) NOT NULL,
+    prefix VARCHAR(255) NULL,
+    suffix VARCHAR(255) NULL,
+    queue INTEGER NOT NULL DEFAULT 1,
+    `separator` VARCHAR(255) NOT NULL DEFAULT '',
+    created_at DATETIME NULL,
+    updated_at DATETIME NULL,
+    deleted_at DATETIME NULL
+);
+
+CREATE UNIQUE INDEX IF NOT EXISTS generates_alias_unique ON generates (alias);
+
+INSERT INTO generates (alias, prefix, queue, `separator`, created_at, updated_at)
+SELECT 'gallery_group', 'GL-', 1, '', CURRENT_TIMESTAMP, CURRENT_TIMESTAMP
+WHERE NOT EXISTS (SELECT 1 FROM generates WHERE alias = 'gallery_group');
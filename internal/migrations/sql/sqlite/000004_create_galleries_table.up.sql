@@ -0,0 +1,22 @@
+CREATE TABLE IF NOT EXISTS galleries (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    user_id INTEGER NOT NULL,
+    subject_id INTEGER NULL,
+    subject_type VARCHAR(255) NULL,
+    file_name VARCHAR(255) NOT NULL,
+    file_path VARCHAR(255) NOT NULL,
+    file_size INTEGER NOT NULL DEFAULT 0,
+    is_private BOOLEAN NOT NULL DEFAULT 0,
+    description TEXT NULL,
+    size VARCHAR(20) NOT NULL DEFAULT 'original',
+    has_optimized BOOLEAN NOT NULL DEFAULT 0,
+    group_code VARCHAR(50) NOT NULL,
+    created_at DATETIME NULL,
+    updated_at DATETIME NULL,
+    deleted_at DATETIME NULL
+);
+
+CREATE INDEX IF NOT EXISTS galleries_user_id_index ON galleries (user_id);
+CREATE INDEX IF NOT EXISTS galleries_subject_type_subject_id_index ON galleries (subject_type, subject_id);
+CREATE INDEX IF NOT EXISTS galleries_group_code_size_index ON galleries (group_code, size);
+CREATE INDEX IF NOT EXISTS galleries_deleted_at_index ON galleries (deleted_at);
@@ -0,0 +1,5 @@
+DROP INDEX IF EXISTS galleries_file_path_index;
+DROP INDEX IF EXISTS galleries_checksum_index;
+
+ALTER TABLE galleries DROP COLUMN checksum;
+ALTER TABLE galleries DROP COLUMN format;
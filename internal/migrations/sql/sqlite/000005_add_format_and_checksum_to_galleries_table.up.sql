@@ -0,0 +1,5 @@
+ALTER TABLE galleries ADD COLUMN format VARCHAR(10) NOT NULL DEFAULT '';
+ALTER TABLE galleries ADD COLUMN checksum CHAR(64) NULL;
+
+CREATE INDEX galleries_checksum_index ON galleries (checksum);
+CREATE INDEX galleries_file_path_index ON galleries (file_path);
@@ -0,0 +1 @@
+DROP INDEX IF EXISTS galleries_original_group_code_unique;
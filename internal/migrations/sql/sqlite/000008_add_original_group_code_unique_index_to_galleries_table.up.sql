@@ -0,0 +1 @@
+CREATE UNIQUE INDEX galleries_original_group_code_unique ON galleries (group_code) WHERE size = 'original';
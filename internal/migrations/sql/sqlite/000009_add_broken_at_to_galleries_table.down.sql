@@ -0,0 +1 @@
+ALTER TABLE galleries DROP COLUMN broken_at;
@@ -0,0 +1 @@
+ALTER TABLE galleries ADD COLUMN broken_at DATETIME NULL;
@@ -0,0 +1,5 @@
+DROP INDEX IF EXISTS galleries_file_size_index;
+DROP INDEX IF EXISTS galleries_created_at_index;
+
+ALTER TABLE galleries DROP COLUMN height;
+ALTER TABLE galleries DROP COLUMN width;
@@ -0,0 +1,5 @@
+ALTER TABLE galleries ADD COLUMN width INTEGER NOT NULL DEFAULT 0;
+ALTER TABLE galleries ADD COLUMN height INTEGER NOT NULL DEFAULT 0;
+
+CREATE INDEX galleries_created_at_index ON galleries (created_at);
+CREATE INDEX galleries_file_size_index ON galleries (file_size);
@@ -0,0 +1,2 @@
+DROP INDEX IF EXISTS galleries_mime_type_index;
+ALTER TABLE galleries DROP COLUMN mime_type;
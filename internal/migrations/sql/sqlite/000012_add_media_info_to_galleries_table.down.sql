@@ -0,0 +1,3 @@
+ALTER TABLE galleries DROP COLUMN bitrate;
+ALTER TABLE galleries DROP COLUMN codec;
+ALTER TABLE galleries DROP COLUMN duration;
@@ -0,0 +1,3 @@
+ALTER TABLE galleries ADD COLUMN duration REAL NULL;
+ALTER TABLE galleries ADD COLUMN codec VARCHAR(50) NULL;
+ALTER TABLE galleries ADD COLUMN bitrate INTEGER NULL;
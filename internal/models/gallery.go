@@ -2,28 +2,39 @@ package models
 
 import (
 	"nova-cdn/internal/config"
+	"nova-cdn/internal/signing"
+	"nova-cdn/internal/storage"
 	"time"
 
 	"gorm.io/gorm"
 )
 
 type Gallery struct {
-	ID            uint           `gorm:"primaryKey" json:"id"`
-	UserID        uint           `json:"user_id"`
-	SubjectID     *uint          `json:"subject_id"`
-	SubjectType   *string        `json:"subject_type"`
-	FileName      string         `json:"file_name"`
-	FilePath      string         `json:"file_path"`
-	Url           string         `gorm:"-" json:"url"`
-	FileSize      uint32         `json:"file_size"`
-	IsPrivate     bool           `json:"is_private"`
-	Description   string         `json:"description"`
-	Size          string         `json:"size"`
-	HasOptimized  bool           `json:"has_optimized"`
-	GroupCode     string         `json:"group_code"`
-	CreatedAt     time.Time      `json:"created_at"`
-	UpdatedAt     time.Time      `json:"updated_at"`
-	DeletedAt     gorm.DeletedAt `json:"deleted_at" swaggertype:"string"`
+	ID           uint           `gorm:"primaryKey" json:"id"`
+	UserID       uint           `json:"user_id"`
+	SubjectID    *uint          `json:"subject_id"`
+	SubjectType  *string        `json:"subject_type"`
+	FileName     string         `json:"file_name"`
+	FilePath     string         `json:"file_path"`
+	Url          string         `gorm:"-" json:"url"`
+	FileSize     uint32         `json:"file_size"`
+	Checksum     string         `json:"checksum"`
+	Width        uint           `json:"width"`
+	Height       uint           `json:"height"`
+	Duration     *float64       `json:"duration"`
+	Codec        *string        `json:"codec"`
+	Bitrate      *uint64        `json:"bitrate"`
+	IsPrivate    bool           `json:"is_private"`
+	Description  string         `json:"description"`
+	Size         string         `json:"size"`
+	Format       string         `json:"format"`
+	MimeType     string         `json:"mime_type"`
+	HasOptimized bool           `json:"has_optimized"`
+	GroupCode    string         `json:"group_code"`
+	BrokenAt     *time.Time     `json:"broken_at"`
+	CreatedAt    time.Time      `json:"created_at"`
+	UpdatedAt    time.Time      `json:"updated_at"`
+	DeletedAt    gorm.DeletedAt `json:"deleted_at" swaggertype:"string"`
 }
 
 func (Gallery) TableName() string {
@@ -31,8 +42,15 @@ func (Gallery) TableName() string {
 }
 
 func (g *Gallery) AfterFind(tx *gorm.DB) error {
-	if g.FilePath != "" {
-		g.Url = config.AppURL + "/" + g.FilePath
+	if g.FilePath == "" {
+		return nil
 	}
+
+	if g.IsPrivate {
+		g.Url, _ = signing.URL(g.GroupCode, g.Size, g.Format, config.SignedURLTTL)
+		return nil
+	}
+
+	g.Url = storage.GetStorage().Public.URL(g.FilePath)
 	return nil
 }
@@ -0,0 +1,29 @@
+package models
+
+import "time"
+
+const (
+	JobStatusPending    = "pending"
+	JobStatusProcessing = "processing"
+	JobStatusDone       = "done"
+	JobStatusFailed     = "failed"
+)
+
+type ImageJob struct {
+	ID          uint       `gorm:"primaryKey" json:"id"`
+	GalleryID   uint       `json:"gallery_id"`
+	GroupCode   string     `json:"group_code"`
+	Status      string     `json:"status"`
+	Attempts    int        `json:"attempts"`
+	MaxAttempts int        `json:"max_attempts"`
+	LastError   *string    `json:"last_error"`
+	AvailableAt time.Time  `json:"available_at"`
+	StartedAt   *time.Time `json:"started_at"`
+	FinishedAt  *time.Time `json:"finished_at"`
+	CreatedAt   time.Time  `json:"created_at"`
+	UpdatedAt   time.Time  `json:"updated_at"`
+}
+
+func (ImageJob) TableName() string {
+	return "image_jobs"
+}
@@ -1,6 +1,29 @@
 package models
 
-import "time"
+import (
+	"encoding/json"
+	"strings"
+	"time"
+)
+
+const (
+	AbilityAll                = "*"
+	AbilityGalleryRead        = "gallery:read"
+	AbilityGalleryWrite       = "gallery:write"
+	AbilityGalleryDelete      = "gallery:delete"
+	AbilityGalleryForceDelete = "gallery:force-delete"
+	AbilityTokenManage        = "token:manage"
+)
+
+// Abilities lists every ability a token can be granted besides the "*" and
+// "<resource>:*" wildcards.
+var Abilities = []string{
+	AbilityGalleryRead,
+	AbilityGalleryWrite,
+	AbilityGalleryDelete,
+	AbilityGalleryForceDelete,
+	AbilityTokenManage,
+}
 
 type PersonalAccessToken struct {
 	ID            uint       `json:"id" gorm:"primaryKey"`
@@ -18,3 +41,45 @@ type PersonalAccessToken struct {
 func (PersonalAccessToken) TableName() string {
 	return "personal_access_tokens"
 }
+
+// AbilityList decodes the JSON encoded abilities column. A malformed value
+// grants nothing.
+func (t PersonalAccessToken) AbilityList() []string {
+	var abilities []string
+	if err := json.Unmarshal([]byte(t.Abilities), &abilities); err != nil {
+		return nil
+	}
+	return abilities
+}
+
+// Can reports whether the token grants ability, either directly, through "*"
+// or through a resource wildcard such as "gallery:*".
+func (t PersonalAccessToken) Can(ability string) bool {
+	resource, _, _ := strings.Cut(ability, ":")
+
+	for _, granted := range t.AbilityList() {
+		if granted == AbilityAll || granted == ability || granted == resource+":*" {
+			return true
+		}
+	}
+	return false
+}
+
+func IsValidAbility(ability string) bool {
+	if ability == AbilityAll {
+		return true
+	}
+
+	for _, known := range Abilities {
+		resource, _, _ := strings.Cut(known, ":")
+		if ability == known || ability == resource+":*" {
+			return true
+		}
+	}
+	return false
+}
+
+func EncodeAbilities(abilities []string) string {
+	encoded, _ := json.Marshal(abilities)
+	return string(encoded)
+}
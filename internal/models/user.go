@@ -2,14 +2,25 @@ package models
 
 import "time"
 
+const (
+	RoleUser  = "user"
+	RoleAdmin = "admin"
+)
+
 type User struct {
 	ID                   uint       `json:"id" gorm:"primaryKey"`
 	Name                 string     `json:"name"`
 	Email                string     `json:"email" gorm:"unique"`
 	Password             string     `json:"-"`
+	Role                 string     `json:"role" gorm:"default:user"`
 	HasAllowNotification *bool      `json:"has_allow_notification"`
 	NotificationToken    *string    `json:"notification_token,omitempty"`
 	CreatedAt            time.Time  `json:"created_at"`
 	UpdatedAt            time.Time  `json:"updated_at"`
 	DeletedAt            *time.Time `json:"deleted_at,omitempty" gorm:"index"`
 }
+
+// IsAdmin reports whether the user can see and manage every user's galleries.
+func (u User) IsAdmin() bool {
+	return u.Role == RoleAdmin
+}
@@ -0,0 +1,203 @@
+package preview
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"image"
+	"image/png"
+	"io"
+	"os"
+	"os/exec"
+	"strconv"
+	"strings"
+	"time"
+
+	"nova-cdn/internal/config"
+)
+
+// FFmpeg probes videos with ffprobe and extracts frames with ffmpeg.
+type FFmpeg struct {
+	FfmpegPath  string
+	FfprobePath string
+	Timeout     time.Duration
+}
+
+// NewFFmpeg locates the binaries, from FFMPEG_PATH and FFPROBE_PATH or on the
+// PATH, and fails when either is missing.
+func NewFFmpeg() (*FFmpeg, error) {
+	ffmpegPath, err := lookPath(config.FfmpegPath, "ffmpeg")
+	if err != nil {
+		return nil, err
+	}
+
+	ffprobePath, err := lookPath(config.FfprobePath, "ffprobe")
+	if err != nil {
+		return nil, err
+	}
+
+	return &FFmpeg{FfmpegPath: ffmpegPath, FfprobePath: ffprobePath, Timeout: config.VideoProbeTimeout}, nil
+}
+
+func lookPath(configured, name string) (string, error) {
+	if configured == "" {
+		configured = name
+	}
+
+	path, err := exec.LookPath(configured)
+	if err != nil {
+		return "", fmt.Errorf("%s is not available: %w", name, err)
+	}
+	return path, nil
+}
+
+type ffprobeOutput struct {
+	Streams []struct {
+		CodecName string `json:"codec_name"`
+		Width     int    `json:"width"`
+		Height    int    `json:"height"`
+		BitRate   string `json:"bit_rate"`
+		Duration  string `json:"duration"`
+	} `json:"streams"`
+	Format struct {
+		BitRate  string `json:"bit_rate"`
+		Duration string `json:"duration"`
+	} `json:"format"`
+}
+
+func (f *FFmpeg) Probe(r io.ReadSeeker) (*MediaInfo, error) {
+	input, cleanup, err := localFile(r)
+	if err != nil {
+		return nil, err
+	}
+	defer cleanup()
+
+	out, err := f.run(f.FfprobePath,
+		"-v", "error",
+		"-select_streams", "v:0",
+		"-show_entries", "stream=codec_name,width,height,bit_rate,duration:format=bit_rate,duration",
+		"-of", "json",
+		input,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	var probed ffprobeOutput
+	if err := json.Unmarshal(out, &probed); err != nil {
+		return nil, fmt.Errorf("failed to parse ffprobe output: %w", err)
+	}
+
+	if len(probed.Streams) == 0 {
+		return nil, fmt.Errorf("no video stream found")
+	}
+
+	stream := probed.Streams[0]
+	info := &MediaInfo{
+		Width:  stream.Width,
+		Height: stream.Height,
+		Codec:  stream.CodecName,
+	}
+
+	// Containers don't always carry per stream values, the format ones cover
+	// the whole file.
+	info.Duration = parseSeconds(firstNonEmpty(probed.Format.Duration, stream.Duration))
+	info.Bitrate, _ = strconv.ParseUint(firstNonEmpty(stream.BitRate, probed.Format.BitRate), 10, 64)
+
+	return info, nil
+}
+
+func (f *FFmpeg) Frame(r io.ReadSeeker, at time.Duration) (image.Image, error) {
+	input, cleanup, err := localFile(r)
+	if err != nil {
+		return nil, err
+	}
+	defer cleanup()
+
+	out, err := f.run(f.FfmpegPath,
+		"-v", "error",
+		"-ss", strconv.FormatFloat(at.Seconds(), 'f', 3, 64),
+		"-i", input,
+		"-frames:v", "1",
+		"-f", "image2pipe",
+		"-vcodec", "png",
+		"-",
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	// ffmpeg exits successfully without output when seeking past the end.
+	if len(out) == 0 {
+		return nil, fmt.Errorf("no frame at %s", at)
+	}
+
+	return png.Decode(bytes.NewReader(out))
+}
+
+func (f *FFmpeg) run(name string, args ...string) ([]byte, error) {
+	ctx := context.Background()
+	if f.Timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, f.Timeout)
+		defer cancel()
+	}
+
+	var stdout, stderr bytes.Buffer
+	cmd := exec.CommandContext(ctx, name, args...)
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+
+	if err := cmd.Run(); err != nil {
+		return nil, fmt.Errorf("%s failed: %w: %s", name, err, strings.TrimSpace(stderr.String()))
+	}
+
+	return stdout.Bytes(), nil
+}
+
+// localFile returns a path ffmpeg can read r from, copying it to a temporary
+// file unless it already is one, e.g. when stored on S3.
+func localFile(r io.ReadSeeker) (string, func(), error) {
+	if f, ok := r.(*os.File); ok {
+		return f.Name(), func() {}, nil
+	}
+
+	if _, err := r.Seek(0, io.SeekStart); err != nil {
+		return "", nil, err
+	}
+
+	tmp, err := os.CreateTemp("", "nova-cdn-*")
+	if err != nil {
+		return "", nil, err
+	}
+	cleanup := func() { os.Remove(tmp.Name()) }
+
+	_, err = io.Copy(tmp, r)
+	if closeErr := tmp.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		cleanup()
+		return "", nil, err
+	}
+
+	return tmp.Name(), cleanup, nil
+}
+
+func parseSeconds(value string) time.Duration {
+	seconds, err := strconv.ParseFloat(value, 64)
+	if err != nil || seconds < 0 {
+		return 0
+	}
+	return time.Duration(seconds * float64(time.Second))
+}
+
+func firstNonEmpty(values ...string) string {
+	for _, v := range values {
+		if v != "" && v != "N/A" {
+			return v
+		}
+	}
+	return ""
+}
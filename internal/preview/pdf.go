@@ -0,0 +1,352 @@
+package preview
+
+import (
+	"errors"
+	"fmt"
+	"image"
+	"image/color"
+	"image/draw"
+	"io"
+	"math"
+	"time"
+
+	"nova-cdn/internal/config"
+
+	"github.com/pdfcpu/pdfcpu/pkg/api"
+	"github.com/pdfcpu/pdfcpu/pkg/filter"
+	"github.com/pdfcpu/pdfcpu/pkg/pdfcpu/model"
+	"github.com/pdfcpu/pdfcpu/pkg/pdfcpu/types"
+)
+
+// maxStreamSize bounds the decoded size of a single stream, and of the
+// content of the page, so a small compressed stream can't inflate into
+// gigabytes.
+const maxStreamSize = 64 << 20
+
+var (
+	errStreamTooLarge = errors.New("pdf stream exceeds the decoded size limit")
+	errRenderTimeout  = errors.New("pdf rendering timed out")
+)
+
+func init() {
+	// pdfcpu otherwise writes a configuration directory in the user's home,
+	// and exits the process when it can't.
+	api.DisableConfigDir()
+}
+
+// PDFRenderer rasterizes the first page of a PDF in pure Go. Paths, images and
+// text are drawn; text uses the Go fonts in place of the embedded ones, which
+// is close enough for thumbnails. Shadings, patterns and clipping are skipped.
+type PDFRenderer struct {
+	// Width of the rendered page in pixels, the height follows the page.
+	Width int
+	// MaxOperations bounds the content stream operators run for one page.
+	MaxOperations int
+	// Timeout bounds the time spent running them, an operator can be as
+	// costly as filling the whole page.
+	Timeout time.Duration
+}
+
+func NewPDFRenderer() *PDFRenderer {
+	return &PDFRenderer{Width: 1600, MaxOperations: 1000000, Timeout: config.PDFRenderTimeout}
+}
+
+func (p *PDFRenderer) Render(r io.ReadSeeker) (img image.Image, err error) {
+	// A malformed file must fail the job, not the worker.
+	defer func() {
+		if rec := recover(); rec != nil {
+			img, err = nil, fmt.Errorf("failed to render pdf: %v", rec)
+		}
+	}()
+
+	if _, err := r.Seek(0, io.SeekStart); err != nil {
+		return nil, err
+	}
+
+	conf := model.NewDefaultConfiguration()
+	conf.ValidationMode = model.ValidationRelaxed
+
+	ctx, err := api.ReadContext(r, conf)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read pdf: %w", err)
+	}
+
+	if err := ctx.EnsurePageCount(); err != nil {
+		return nil, fmt.Errorf("failed to read pdf pages: %w", err)
+	}
+
+	if ctx.PageCount < 1 {
+		return nil, fmt.Errorf("pdf has no pages")
+	}
+
+	pageDict, _, inherited, err := ctx.PageDict(1, false)
+	if err != nil || pageDict == nil {
+		return nil, fmt.Errorf("failed to read pdf page: %v", err)
+	}
+
+	box := &types.Rectangle{LL: types.Point{X: 0, Y: 0}, UR: types.Point{X: 612, Y: 792}}
+	if inherited.MediaBox != nil {
+		box = inherited.MediaBox
+	}
+	if inherited.CropBox != nil {
+		box = inherited.CropBox
+	}
+
+	if box.Width() <= 0 || box.Height() <= 0 {
+		return nil, fmt.Errorf("invalid pdf page size")
+	}
+
+	scale := float64(p.Width) / box.Width()
+	height := int(math.Ceil(box.Height() * scale))
+	if height < 1 || height > p.Width*8 {
+		return nil, fmt.Errorf("unsupported pdf page ratio")
+	}
+
+	dst := image.NewRGBA(image.Rect(0, 0, p.Width, height))
+	draw.Draw(dst, dst.Bounds(), image.NewUniform(color.White), image.Point{}, draw.Src)
+
+	page := &pdfPage{
+		xref:          ctx.XRefTable,
+		dst:           dst,
+		fonts:         map[types.IndirectRef]*pdfFont{},
+		maxOperations: p.MaxOperations,
+	}
+
+	content, err := page.content(pageDict["Contents"])
+	if err != nil {
+		return nil, fmt.Errorf("failed to read pdf page content: %w", err)
+	}
+
+	if p.Timeout > 0 {
+		page.deadline = time.Now().Add(p.Timeout)
+	}
+
+	base := matrix{scale, 0, 0, -scale, -box.LL.X * scale, box.UR.Y * scale}
+	page.run(content, inherited.Resources, newGraphicsState(base), 0)
+
+	if page.err != nil {
+		return nil, page.err
+	}
+
+	return rotate(dst, inherited.Rotate), nil
+}
+
+// rotate turns the rendered page clockwise by the /Rotate of the page.
+func rotate(src *image.RGBA, degrees int) image.Image {
+	degrees = ((degrees % 360) + 360) % 360
+	if degrees == 0 || degrees%90 != 0 {
+		return src
+	}
+
+	b := src.Bounds()
+	w, h := b.Dx(), b.Dy()
+
+	var dst *image.RGBA
+	if degrees == 180 {
+		dst = image.NewRGBA(image.Rect(0, 0, w, h))
+	} else {
+		dst = image.NewRGBA(image.Rect(0, 0, h, w))
+	}
+
+	for y := 0; y < h; y++ {
+		for x := 0; x < w; x++ {
+			c := src.RGBAAt(x, y)
+			switch degrees {
+			case 90:
+				dst.SetRGBA(h-1-y, x, c)
+			case 180:
+				dst.SetRGBA(w-1-x, h-1-y, c)
+			case 270:
+				dst.SetRGBA(y, w-1-x, c)
+			}
+		}
+	}
+
+	return dst
+}
+
+// matrix is a PDF transformation matrix [a b c d e f].
+type matrix [6]float64
+
+var identity = matrix{1, 0, 0, 1, 0, 0}
+
+// mul returns the transformation applying m first, then n.
+func (m matrix) mul(n matrix) matrix {
+	return matrix{
+		m[0]*n[0] + m[1]*n[2],
+		m[0]*n[1] + m[1]*n[3],
+		m[2]*n[0] + m[3]*n[2],
+		m[2]*n[1] + m[3]*n[3],
+		m[4]*n[0] + m[5]*n[2] + n[4],
+		m[4]*n[1] + m[5]*n[3] + n[5],
+	}
+}
+
+func (m matrix) apply(x, y float64) (float64, float64) {
+	return m[0]*x + m[2]*y + m[4], m[1]*x + m[3]*y + m[5]
+}
+
+// scale is the mean length a unit vector gets under m.
+func (m matrix) scale() float64 {
+	return math.Sqrt(math.Abs(m[0]*m[3] - m[1]*m[2]))
+}
+
+func translate(x, y float64) matrix {
+	return matrix{1, 0, 0, 1, x, y}
+}
+
+// The helpers below read pdfcpu objects, resolving indirect references.
+
+func (pg *pdfPage) resolve(o types.Object) types.Object {
+	for i := 0; i < 8; i++ {
+		ref, ok := o.(types.IndirectRef)
+		if !ok {
+			return o
+		}
+		resolved, err := pg.xref.Dereference(ref)
+		if err != nil {
+			return nil
+		}
+		o = resolved
+	}
+	return o
+}
+
+func (pg *pdfPage) dict(o types.Object) types.Dict {
+	switch v := pg.resolve(o).(type) {
+	case types.Dict:
+		return v
+	case types.StreamDict:
+		return v.Dict
+	}
+	return nil
+}
+
+func (pg *pdfPage) array(o types.Object) types.Array {
+	a, _ := pg.resolve(o).(types.Array)
+	return a
+}
+
+func (pg *pdfPage) number(o types.Object) (float64, bool) {
+	switch v := pg.resolve(o).(type) {
+	case types.Integer:
+		return float64(v), true
+	case types.Float:
+		return float64(v), true
+	}
+	return 0, false
+}
+
+func (pg *pdfPage) name(o types.Object) string {
+	n, _ := pg.resolve(o).(types.Name)
+	return string(n)
+}
+
+func (pg *pdfPage) bytes(o types.Object) []byte {
+	switch v := pg.resolve(o).(type) {
+	case types.StringLiteral:
+		b, err := types.Unescape(string(v))
+		if err != nil {
+			return nil
+		}
+		return b
+	case types.HexLiteral:
+		b, _ := v.Bytes()
+		return b
+	case types.StreamDict:
+		if err := decode(&v); err != nil {
+			return nil
+		}
+		return v.Content
+	}
+	return nil
+}
+
+// content returns the content of the page, a single stream or an array of
+// streams concatenated in order.
+func (pg *pdfPage) content(o types.Object) ([]byte, error) {
+	streams := pg.array(o)
+	if streams == nil {
+		streams = types.Array{o}
+	}
+
+	var content []byte
+	for _, s := range streams {
+		sd, ok := pg.resolve(s).(types.StreamDict)
+		if !ok {
+			continue
+		}
+
+		if err := decode(&sd); err != nil {
+			return nil, err
+		}
+
+		if len(content)+len(sd.Content) > maxStreamSize {
+			return nil, errStreamTooLarge
+		}
+
+		// Operators may not span streams, but tokens need a separator.
+		content = append(content, sd.Content...)
+		content = append(content, '\n')
+	}
+
+	return content, nil
+}
+
+// expandingFilters can produce much more data than they read.
+var expandingFilters = map[string]bool{
+	filter.Flate:     true,
+	filter.LZW:       true,
+	filter.RunLength: true,
+}
+
+// decode decodes sd into sd.Content unless that exceeds maxStreamSize. pdfcpu
+// only bounds the last filter of a pipeline, so pipelines expanding earlier
+// are refused, as are image codecs which are decoded separately, if at all.
+func decode(sd *types.StreamDict) error {
+	if sd.Content != nil {
+		return nil
+	}
+
+	for i, f := range sd.FilterPipeline {
+		switch f.Name {
+		case filter.DCT, filter.JPX, filter.JBIG2, filter.CCITTFax:
+			return fmt.Errorf("unsupported pdf stream filter %s", f.Name)
+		}
+		if expandingFilters[f.Name] && i < len(sd.FilterPipeline)-1 {
+			return fmt.Errorf("unsupported pdf stream filter pipeline")
+		}
+	}
+
+	// Decoding one byte past the limit tells whether the stream is larger.
+	// Smaller streams fail this probe, as pdfcpu expects at least that many
+	// bytes, and are then decoded fully, which is bounded by the limit.
+	if probe, err := decodeLength(sd, maxStreamSize+1); err == nil && len(probe) > maxStreamSize {
+		return errStreamTooLarge
+	}
+
+	return sd.Decode()
+}
+
+func decodeLength(sd *types.StreamDict, maxLen int64) (data []byte, err error) {
+	// pdfcpu slices the decoded data to maxLen without checking its length.
+	defer func() {
+		if rec := recover(); rec != nil {
+			data, err = nil, fmt.Errorf("failed to decode pdf stream: %v", rec)
+		}
+	}()
+
+	return sd.DecodeLength(maxLen)
+}
+
+// resource looks up a named entry of a resource category, e.g. /Font /F1.
+func (pg *pdfPage) resource(resources types.Dict, category, name string) types.Object {
+	if resources == nil {
+		return nil
+	}
+	entries := pg.dict(resources[category])
+	if entries == nil {
+		return nil
+	}
+	return entries[name]
+}
@@ -0,0 +1,202 @@
+package preview
+
+import (
+	"image/color"
+
+	"github.com/pdfcpu/pdfcpu/pkg/pdfcpu/types"
+)
+
+// colorSpace converts color components to RGB. Calibrated and ICC based
+// spaces are treated as their device counterparts, and separations as shades
+// of gray.
+type colorSpace struct {
+	kind       string
+	components int
+	pattern    bool
+
+	// Indexed spaces.
+	base   *colorSpace
+	hival  int
+	lookup []byte
+}
+
+var (
+	deviceGray = colorSpace{kind: "DeviceGray", components: 1}
+	deviceRGB  = colorSpace{kind: "DeviceRGB", components: 3}
+	deviceCMYK = colorSpace{kind: "DeviceCMYK", components: 4}
+)
+
+// initial is the color a space starts with when selected.
+func (cs colorSpace) initial() color.NRGBA {
+	if cs.kind == "Indexed" {
+		return cs.rgb([]float64{0})
+	}
+	return color.NRGBA{A: 0xff}
+}
+
+func (cs colorSpace) rgb(v []float64) color.NRGBA {
+	at := func(i int) float64 {
+		if i < len(v) {
+			return clamp01(v[i])
+		}
+		return 0
+	}
+
+	switch cs.kind {
+	case "DeviceGray":
+		return gray(at(0))
+	case "DeviceRGB":
+		return color.NRGBA{channel(at(0)), channel(at(1)), channel(at(2)), 0xff}
+	case "DeviceCMYK":
+		k := 1 - at(3)
+		return color.NRGBA{channel((1 - at(0)) * k), channel((1 - at(1)) * k), channel((1 - at(2)) * k), 0xff}
+	case "Lab":
+		if len(v) > 0 {
+			return gray(v[0] / 100)
+		}
+	case "Separation", "DeviceN":
+		tint := 0.0
+		for i := range v {
+			if at(i) > tint {
+				tint = at(i)
+			}
+		}
+		return gray(1 - tint)
+	case "Indexed":
+		if cs.base == nil || len(v) == 0 {
+			break
+		}
+		i := int(v[0])
+		if i < 0 {
+			i = 0
+		}
+		if i > cs.hival {
+			i = cs.hival
+		}
+		n := cs.base.components
+		if (i+1)*n > len(cs.lookup) {
+			break
+		}
+		components := make([]float64, n)
+		for j := range components {
+			components[j] = float64(cs.lookup[i*n+j]) / 255
+		}
+		return cs.base.rgb(components)
+	}
+
+	return color.NRGBA{A: 0xff}
+}
+
+func gray(v float64) color.NRGBA {
+	g := channel(v)
+	return color.NRGBA{g, g, g, 0xff}
+}
+
+func channel(v float64) uint8 {
+	return uint8(clamp01(v)*255 + 0.5)
+}
+
+// setColor runs the g, rg, k and sc operators and their stroking variants.
+func (pg *pdfPage) setColor(gs *graphicsState, op string, operands []interface{}) {
+	stroking := op == "G" || op == "RG" || op == "K" || op == "SC" || op == "SCN"
+	space := &gs.fillSpace
+	target := &gs.fill
+	if stroking {
+		space = &gs.strokeSpace
+		target = &gs.stroke
+	}
+
+	switch op {
+	case "g", "G":
+		*space = deviceGray
+	case "rg", "RG":
+		*space = deviceRGB
+	case "k", "K":
+		*space = deviceCMYK
+	}
+
+	if space.pattern {
+		return
+	}
+
+	*target = space.rgb(numbers(operands))
+}
+
+func (pg *pdfPage) colorSpace(resources types.Dict, name string) colorSpace {
+	switch name {
+	case "DeviceGray", "G", "DeviceRGB", "RGB", "DeviceCMYK", "CMYK", "Pattern":
+		return pg.parseColorSpace(types.Name(name), 0)
+	}
+	return pg.parseColorSpace(pg.resource(resources, "ColorSpace", name), 0)
+}
+
+func (pg *pdfPage) parseColorSpace(o types.Object, depth int) colorSpace {
+	if depth > 4 {
+		return deviceGray
+	}
+
+	switch v := pg.resolve(o).(type) {
+	case types.Name:
+		switch string(v) {
+		case "DeviceRGB", "RGB", "CalRGB":
+			return deviceRGB
+		case "DeviceCMYK", "CMYK":
+			return deviceCMYK
+		case "Pattern":
+			return colorSpace{kind: "Pattern", pattern: true}
+		}
+		return deviceGray
+
+	case types.Array:
+		if len(v) == 0 {
+			return deviceGray
+		}
+
+		switch pg.name(v[0]) {
+		case "ICCBased":
+			if len(v) > 1 {
+				if n, ok := pg.number(pg.dict(v[1])["N"]); ok {
+					switch int(n) {
+					case 3:
+						return deviceRGB
+					case 4:
+						return deviceCMYK
+					}
+				}
+			}
+			return deviceGray
+		case "CalRGB":
+			return deviceRGB
+		case "Lab":
+			return colorSpace{kind: "Lab", components: 3}
+		case "Separation":
+			return colorSpace{kind: "Separation", components: 1}
+		case "DeviceN":
+			n := 1
+			if len(v) > 1 {
+				if names := pg.array(v[1]); len(names) > 0 {
+					n = len(names)
+				}
+			}
+			return colorSpace{kind: "DeviceN", components: n}
+		case "Pattern":
+			return colorSpace{kind: "Pattern", pattern: true}
+		case "Indexed", "I":
+			if len(v) < 4 {
+				return deviceGray
+			}
+			base := pg.parseColorSpace(v[1], depth+1)
+			hival, _ := pg.number(v[2])
+			return colorSpace{
+				kind:       "Indexed",
+				components: 1,
+				base:       &base,
+				hival:      int(hival),
+				lookup:     pg.bytes(v[3]),
+			}
+		}
+		return pg.parseColorSpace(v[0], depth+1)
+	}
+
+	return deviceGray
+}
@@ -0,0 +1,670 @@
+package preview
+
+import (
+	"strconv"
+	"strings"
+	"sync"
+	"unicode/utf16"
+
+	"github.com/pdfcpu/pdfcpu/pkg/pdfcpu/types"
+	"golang.org/x/image/font"
+	"golang.org/x/image/font/gofont/gobold"
+	"golang.org/x/image/font/gofont/gobolditalic"
+	"golang.org/x/image/font/gofont/goitalic"
+	"golang.org/x/image/font/gofont/gomono"
+	"golang.org/x/image/font/gofont/gomonobold"
+	"golang.org/x/image/font/gofont/gomonobolditalic"
+	"golang.org/x/image/font/gofont/gomonoitalic"
+	"golang.org/x/image/font/gofont/goregular"
+	"golang.org/x/image/font/sfnt"
+	"golang.org/x/image/math/fixed"
+)
+
+type textState struct {
+	font      *pdfFont
+	size      float64
+	charSpace float64
+	wordSpace float64
+	scale     float64
+	leading   float64
+	rise      float64
+	render    int
+}
+
+// pdfFont maps the codes of a PDF font to unicode and widths. Glyphs are drawn
+// with a Go font of a similar style, the embedded font programs are not read.
+type pdfFont struct {
+	twoByte      bool
+	symbolic     bool
+	widths       map[int]float64
+	defaultWidth float64
+	encoding     map[int]rune
+	toUnicode    map[int]rune
+	face         *sfnt.Font
+}
+
+type glyph struct {
+	segments []sfnt.Segment
+	// unit converts the fixed point coordinates of segments to ems.
+	unit    float64
+	advance float64
+}
+
+var (
+	facesOnce sync.Once
+	faces     map[string]*sfnt.Font
+)
+
+func loadFaces() {
+	faces = map[string]*sfnt.Font{}
+	for name, ttf := range map[string][]byte{
+		"regular":        goregular.TTF,
+		"bold":           gobold.TTF,
+		"italic":         goitalic.TTF,
+		"bolditalic":     gobolditalic.TTF,
+		"mono":           gomono.TTF,
+		"monobold":       gomonobold.TTF,
+		"monoitalic":     gomonoitalic.TTF,
+		"monobolditalic": gomonobolditalic.TTF,
+	} {
+		if f, err := sfnt.Parse(ttf); err == nil {
+			faces[name] = f
+		}
+	}
+}
+
+// substitute picks the Go font closest to a PDF font.
+func substitute(baseFont string, flags int) *sfnt.Font {
+	facesOnce.Do(loadFaces)
+
+	if i := strings.IndexByte(baseFont, '+'); i >= 0 {
+		baseFont = baseFont[i+1:]
+	}
+	name := strings.ToLower(baseFont)
+
+	key := ""
+	if strings.Contains(name, "courier") || strings.Contains(name, "mono") || flags&1 != 0 {
+		key = "mono"
+	}
+	if strings.Contains(name, "bold") || strings.Contains(name, "black") || strings.Contains(name, "heavy") || flags&(1<<18) != 0 {
+		key += "bold"
+	}
+	if strings.Contains(name, "italic") || strings.Contains(name, "oblique") || flags&(1<<6) != 0 {
+		key += "italic"
+	}
+	if key == "" {
+		key = "regular"
+	}
+
+	return faces[key]
+}
+
+func (pg *pdfPage) font(resources types.Dict, name string) *pdfFont {
+	o := pg.resource(resources, "Font", name)
+	ref, isRef := o.(types.IndirectRef)
+	if isRef {
+		if f, ok := pg.fonts[ref]; ok {
+			return f
+		}
+	}
+
+	f := pg.loadFont(pg.dict(o))
+	if isRef {
+		pg.fonts[ref] = f
+	}
+	return f
+}
+
+func (pg *pdfPage) loadFont(d types.Dict) *pdfFont {
+	f := &pdfFont{widths: map[int]float64{}}
+	if d == nil {
+		f.face = substitute("", 0)
+		return f
+	}
+
+	subtype := pg.name(d["Subtype"])
+	baseFont := pg.name(d["BaseFont"])
+	descriptor := pg.dict(d["FontDescriptor"])
+
+	if subtype == "Type0" {
+		f.twoByte = true
+		if descendants := pg.array(d["DescendantFonts"]); len(descendants) > 0 {
+			descendant := pg.dict(descendants[0])
+			descriptor = pg.dict(descendant["FontDescriptor"])
+			pg.loadCIDWidths(f, descendant)
+		}
+	} else {
+		pg.loadSimpleWidths(f, d, descriptor)
+		f.encoding = pg.loadEncoding(d["Encoding"])
+	}
+
+	flags := 0
+	if n, ok := pg.number(descriptor["Flags"]); ok {
+		flags = int(n)
+	}
+
+	lower := strings.ToLower(baseFont)
+	f.symbolic = subtype == "Type3" || strings.Contains(lower, "symbol") || strings.Contains(lower, "dingbats")
+	f.face = substitute(baseFont, flags)
+
+	if data := pg.bytes(d["ToUnicode"]); len(data) > 0 {
+		f.toUnicode = parseToUnicode(data)
+	}
+
+	// Type 3 glyph widths are in the glyph space of the font matrix.
+	if subtype == "Type3" {
+		if m := pg.array(d["FontMatrix"]); len(m) == 6 {
+			if scale, ok := pg.number(m[0]); ok {
+				for code, w := range f.widths {
+					f.widths[code] = w * scale * 1000
+				}
+			}
+		}
+	}
+
+	return f
+}
+
+func (pg *pdfPage) loadSimpleWidths(f *pdfFont, d, descriptor types.Dict) {
+	first, _ := pg.number(d["FirstChar"])
+	for i, w := range pg.array(d["Widths"]) {
+		if n, ok := pg.number(w); ok {
+			f.widths[int(first)+i] = n / 1000
+		}
+	}
+	if n, ok := pg.number(descriptor["MissingWidth"]); ok && n > 0 {
+		f.defaultWidth = n / 1000
+	}
+}
+
+func (pg *pdfPage) loadCIDWidths(f *pdfFont, d types.Dict) {
+	f.defaultWidth = 1
+	if n, ok := pg.number(d["DW"]); ok {
+		f.defaultWidth = n / 1000
+	}
+
+	w := pg.array(d["W"])
+	for i := 0; i < len(w); {
+		first, ok := pg.number(w[i])
+		if !ok || i+1 >= len(w) {
+			return
+		}
+
+		if widths := pg.array(w[i+1]); widths != nil {
+			for j, width := range widths {
+				if n, ok := pg.number(width); ok {
+					f.widths[int(first)+j] = n / 1000
+				}
+			}
+			i += 2
+			continue
+		}
+
+		if i+2 >= len(w) {
+			return
+		}
+		last, _ := pg.number(w[i+1])
+		width, _ := pg.number(w[i+2])
+		if last-first > 0xffff {
+			return
+		}
+		for c := int(first); c <= int(last); c++ {
+			f.widths[c] = width / 1000
+		}
+		i += 3
+	}
+}
+
+func (pg *pdfPage) loadEncoding(o types.Object) map[int]rune {
+	encoding := map[int]rune{}
+	for code := 0; code < 256; code++ {
+		encoding[code] = winAnsi(byte(code))
+	}
+
+	d := pg.dict(o)
+	if d == nil {
+		return encoding
+	}
+
+	code := 0
+	for _, entry := range pg.array(d["Differences"]) {
+		switch v := pg.resolve(entry).(type) {
+		case types.Integer:
+			code = int(v)
+		case types.Float:
+			code = int(v)
+		case types.Name:
+			if r, ok := glyphRune(string(v)); ok {
+				encoding[code] = r
+			}
+			code++
+		}
+	}
+
+	return encoding
+}
+
+// cp1252 maps the bytes WinAnsiEncoding places differently from Latin-1.
+var cp1252 = map[byte]rune{
+	0x80: '€', 0x82: '‚', 0x83: 'ƒ', 0x84: '„', 0x85: '…', 0x86: '†', 0x87: '‡',
+	0x88: 'ˆ', 0x89: '‰', 0x8a: 'Š', 0x8b: '‹', 0x8c: 'Œ', 0x8e: 'Ž', 0x91: '‘',
+	0x92: '’', 0x93: '“', 0x94: '”', 0x95: '•', 0x96: '–', 0x97: '—', 0x98: '˜',
+	0x99: '™', 0x9a: 'š', 0x9b: '›', 0x9c: 'œ', 0x9e: 'ž', 0x9f: 'Ÿ',
+}
+
+func winAnsi(b byte) rune {
+	if r, ok := cp1252[b]; ok {
+		return r
+	}
+	return rune(b)
+}
+
+// glyphNames covers the names of the Adobe glyph list that are not a single
+// letter, the ones found in most Differences arrays.
+var glyphNames = map[string]rune{
+	"space": ' ', "exclam": '!', "quotedbl": '"', "numbersign": '#', "dollar": '$',
+	"percent": '%', "ampersand": '&', "quotesingle": '\'', "parenleft": '(',
+	"parenright": ')', "asterisk": '*', "plus": '+', "comma": ',', "hyphen": '-',
+	"period": '.', "slash": '/', "zero": '0', "one": '1', "two": '2', "three": '3',
+	"four": '4', "five": '5', "six": '6', "seven": '7', "eight": '8', "nine": '9',
+	"colon": ':', "semicolon": ';', "less": '<', "equal": '=', "greater": '>',
+	"question": '?', "at": '@', "bracketleft": '[', "backslash": '\\',
+	"bracketright": ']', "asciicircum": '^', "underscore": '_', "grave": '`',
+	"braceleft": '{', "bar": '|', "braceright": '}', "asciitilde": '~',
+	"quoteleft": '‘', "quoteright": '’', "quotedblleft": '“', "quotedblright": '”',
+	"bullet": '•', "endash": '–', "emdash": '—', "ellipsis": '…', "minus": '−',
+	"fi": 'ﬁ', "fl": 'ﬂ', "ff": 'ﬀ', "ffi": 'ﬃ', "ffl": 'ﬄ', "degree": '°',
+	"copyright": '©', "registered": '®', "trademark": '™', "section": '§',
+	"paragraph": '¶', "dagger": '†', "daggerdbl": '‡', "Euro": '€', "sterling": '£',
+	"yen": '¥', "cent": '¢', "multiply": '×', "divide": '÷', "periodcentered": '·',
+	"guillemotleft": '«', "guillemotright": '»', "exclamdown": '¡', "questiondown": '¿',
+	"dotlessi": 'ı', "germandbls": 'ß', "ae": 'æ', "AE": 'Æ', "oslash": 'ø',
+	"Oslash": 'Ø', "eacute": 'é', "egrave": 'è', "ecircumflex": 'ê', "edieresis": 'ë',
+	"aacute": 'á', "agrave": 'à', "acircumflex": 'â', "adieresis": 'ä', "atilde": 'ã',
+	"aring": 'å', "ccedilla": 'ç', "iacute": 'í', "igrave": 'ì', "icircumflex": 'î',
+	"idieresis": 'ï', "ntilde": 'ñ', "oacute": 'ó', "ograve": 'ò', "ocircumflex": 'ô',
+	"odieresis": 'ö', "otilde": 'õ', "uacute": 'ú', "ugrave": 'ù', "ucircumflex": 'û',
+	"udieresis": 'ü', "yacute": 'ý', "ydieresis": 'ÿ', "Eacute": 'É', "Egrave": 'È',
+	"Aacute": 'Á', "Agrave": 'À', "Adieresis": 'Ä', "Ccedilla": 'Ç', "Ntilde": 'Ñ',
+	"Odieresis": 'Ö', "Udieresis": 'Ü', "nbspace": ' ', "sfthyphen": '­',
+}
+
+func glyphRune(name string) (rune, bool) {
+	if r, ok := glyphNames[name]; ok {
+		return r, true
+	}
+	if len(name) == 1 {
+		return rune(name[0]), true
+	}
+	if i := strings.IndexByte(name, '.'); i > 0 {
+		return glyphRune(name[:i])
+	}
+	for _, prefix := range []string{"uni", "u"} {
+		if strings.HasPrefix(name, prefix) && len(name) >= len(prefix)+4 {
+			hex := name[len(prefix):]
+			if prefix == "uni" {
+				hex = hex[:4]
+			}
+			if v, err := strconv.ParseUint(hex, 16, 32); err == nil {
+				return rune(v), true
+			}
+		}
+	}
+	return 0, false
+}
+
+// parseToUnicode reads the bfchar and bfrange mappings of a ToUnicode CMap,
+// keeping the first character of every destination.
+func parseToUnicode(data []byte) map[int]rune {
+	m := map[int]rune{}
+	lex := newLexer(data)
+
+	var operands []interface{}
+	mode := ""
+
+	for {
+		token := lex.next()
+		if token == nil {
+			return m
+		}
+
+		op, ok := token.(pdfOperator)
+		if !ok {
+			operands = append(operands, token)
+			continue
+		}
+
+		switch op {
+		case "beginbfchar", "beginbfrange":
+			mode = string(op)
+		case "endbfchar":
+			for i := 0; i+1 < len(operands); i += 2 {
+				src, _ := operands[i].(pdfString)
+				dst, _ := operands[i+1].(pdfString)
+				if r, ok := decodeUTF16(dst); ok {
+					m[codeOf(src)] = r
+				}
+			}
+			mode = ""
+		case "endbfrange":
+			for i := 0; i+2 < len(operands); i += 3 {
+				lo, _ := operands[i].(pdfString)
+				hi, _ := operands[i+1].(pdfString)
+				first, last := codeOf(lo), codeOf(hi)
+				if last < first || last-first > 0xffff {
+					continue
+				}
+
+				switch dst := operands[i+2].(type) {
+				case pdfString:
+					if r, ok := decodeUTF16(dst); ok {
+						for c := first; c <= last; c++ {
+							m[c] = r + rune(c-first)
+						}
+					}
+				case pdfArray:
+					for j, item := range dst {
+						if s, ok := item.(pdfString); ok && first+j <= last {
+							if r, ok := decodeUTF16(s); ok {
+								m[first+j] = r
+							}
+						}
+					}
+				}
+			}
+			mode = ""
+		}
+
+		if mode == "" || op == "beginbfchar" || op == "beginbfrange" {
+			operands = operands[:0]
+		}
+	}
+}
+
+func codeOf(b []byte) int {
+	code := 0
+	for _, c := range b {
+		code = code<<8 | int(c)
+	}
+	return code
+}
+
+func decodeUTF16(b []byte) (rune, bool) {
+	if len(b) < 2 {
+		if len(b) == 1 {
+			return rune(b[0]), true
+		}
+		return 0, false
+	}
+	units := make([]uint16, len(b)/2)
+	for i := range units {
+		units[i] = uint16(b[i*2])<<8 | uint16(b[i*2+1])
+	}
+	runes := utf16.Decode(units)
+	if len(runes) == 0 {
+		return 0, false
+	}
+	return runes[0], true
+}
+
+// codes splits a shown string into character codes.
+func (f *pdfFont) codes(s []byte) []int {
+	var codes []int
+	if f.twoByte {
+		for i := 0; i+1 < len(s); i += 2 {
+			codes = append(codes, int(s[i])<<8|int(s[i+1]))
+		}
+		return codes
+	}
+	for _, b := range s {
+		codes = append(codes, int(b))
+	}
+	return codes
+}
+
+func (f *pdfFont) unicode(code int) (rune, bool) {
+	if r, ok := f.toUnicode[code]; ok {
+		return r, true
+	}
+	if f.twoByte || f.symbolic {
+		return 0, false
+	}
+	r, ok := f.encoding[code]
+	return r, ok
+}
+
+// glyph loads the outline of a rune in units of the em square, y pointing up.
+func (pg *pdfPage) glyph(face *sfnt.Font, r rune) *glyph {
+	key := glyphKey{face, r}
+	if g, ok := pg.glyphs[key]; ok {
+		return g
+	}
+
+	var g *glyph
+	if face != nil {
+		g = loadGlyph(&pg.buffer, face, r)
+	}
+	if pg.glyphs == nil {
+		pg.glyphs = map[glyphKey]*glyph{}
+	}
+	pg.glyphs[key] = g
+	return g
+}
+
+type glyphKey struct {
+	face *sfnt.Font
+	r    rune
+}
+
+func loadGlyph(buf *sfnt.Buffer, face *sfnt.Font, r rune) *glyph {
+	index, err := face.GlyphIndex(buf, r)
+	if err != nil || index == 0 {
+		return nil
+	}
+
+	ppem := fixed.Int26_6(face.UnitsPerEm()) << 6
+	segments, err := face.LoadGlyph(buf, index, ppem, nil)
+	if err != nil {
+		return nil
+	}
+	advance, err := face.GlyphAdvance(buf, index, ppem, font.HintingNone)
+	if err != nil {
+		return nil
+	}
+
+	return &glyph{
+		segments: append([]sfnt.Segment(nil), segments...),
+		unit:     1 / float64(ppem),
+		advance:  float64(advance) / float64(ppem),
+	}
+}
+
+func (pg *pdfPage) textOperator(gs *graphicsState, resources types.Dict, op string, operands []interface{}) {
+	ts := &gs.text
+	n := numbers(operands)
+
+	switch op {
+	case "BT":
+		pg.tm, pg.tlm = identity, identity
+	case "Tf":
+		ts.font = pg.font(resources, nameAt(operands, 0))
+		if size, ok := numberAt(operands, 1); ok {
+			ts.size = size
+		}
+	case "Td", "TD":
+		if len(n) < 2 {
+			return
+		}
+		if op == "TD" {
+			ts.leading = -n[1]
+		}
+		pg.tlm = translate(n[0], n[1]).mul(pg.tlm)
+		pg.tm = pg.tlm
+	case "Tm":
+		if m, ok := matrixOperand(operands); ok {
+			pg.tm, pg.tlm = m, m
+		}
+	case "T*":
+		pg.nextLine(ts)
+	case "Tc":
+		if len(n) > 0 {
+			ts.charSpace = n[0]
+		}
+	case "Tw":
+		if len(n) > 0 {
+			ts.wordSpace = n[0]
+		}
+	case "Tz":
+		if len(n) > 0 {
+			ts.scale = n[0] / 100
+		}
+	case "TL":
+		if len(n) > 0 {
+			ts.leading = n[0]
+		}
+	case "Ts":
+		if len(n) > 0 {
+			ts.rise = n[0]
+		}
+	case "Tr":
+		if len(n) > 0 {
+			ts.render = int(n[0])
+		}
+	case "Tj":
+		if s, ok := lastString(operands); ok {
+			pg.showText(gs, s)
+		}
+	case "'":
+		pg.nextLine(ts)
+		if s, ok := lastString(operands); ok {
+			pg.showText(gs, s)
+		}
+	case "\"":
+		if len(n) >= 2 {
+			ts.wordSpace, ts.charSpace = n[0], n[1]
+		}
+		pg.nextLine(ts)
+		if s, ok := lastString(operands); ok {
+			pg.showText(gs, s)
+		}
+	case "TJ":
+		if len(operands) == 0 {
+			return
+		}
+		array, _ := operands[len(operands)-1].(pdfArray)
+		for _, item := range array {
+			switch v := item.(type) {
+			case pdfString:
+				pg.showText(gs, v)
+			case float64:
+				pg.tm = translate(-v/1000*ts.size*ts.scale, 0).mul(pg.tm)
+			}
+		}
+	}
+}
+
+func lastString(operands []interface{}) (pdfString, bool) {
+	if len(operands) == 0 {
+		return nil, false
+	}
+	s, ok := operands[len(operands)-1].(pdfString)
+	return s, ok
+}
+
+func (pg *pdfPage) nextLine(ts *textState) {
+	pg.tlm = translate(0, -ts.leading).mul(pg.tlm)
+	pg.tm = pg.tlm
+}
+
+// showText draws a string with the current font and advances the text matrix.
+func (pg *pdfPage) showText(gs *graphicsState, s []byte) {
+	ts := &gs.text
+	f := ts.font
+	if f == nil {
+		f = pg.loadFont(nil)
+		ts.font = f
+	}
+
+	visible := ts.render != 3 && ts.render != 7
+	c := withAlpha(gs.fill, gs.fillAlpha)
+	if ts.render == 1 || ts.render == 5 {
+		c = withAlpha(gs.stroke, gs.strokeAlpha)
+	}
+
+	path := pg.path
+	pg.path = nil
+
+	for _, code := range f.codes(s) {
+		var g *glyph
+		if r, ok := f.unicode(code); ok && visible {
+			g = pg.glyph(f.face, r)
+		}
+
+		width, ok := f.widths[code]
+		if !ok {
+			width = f.defaultWidth
+			if width == 0 && g != nil {
+				width = g.advance
+			}
+		}
+
+		if g != nil {
+			// Squeeze or stretch the substitute glyph into the advance the
+			// document expects, within reason.
+			stretch := 1.0
+			if g.advance > 0 && width > 0 {
+				stretch = clamp(width/g.advance, 0.6, 1.4)
+			}
+
+			trm := matrix{ts.size * ts.scale * stretch, 0, 0, ts.size, 0, ts.rise}.mul(pg.tm).mul(gs.ctm)
+			pg.appendGlyph(g, trm)
+		}
+
+		tx := width*ts.size + ts.charSpace
+		if !f.twoByte && code == 32 {
+			tx += ts.wordSpace
+		}
+		pg.tm = translate(tx*ts.scale, 0).mul(pg.tm)
+	}
+
+	glyphs := pg.path
+	pg.path = path
+	pg.fill(glyphs, c)
+}
+
+// appendGlyph adds the outline of a glyph to the current path.
+func (pg *pdfPage) appendGlyph(g *glyph, trm matrix) {
+	at := func(p fixed.Point26_6) point {
+		x, y := trm.apply(float64(p.X)*g.unit, -float64(p.Y)*g.unit)
+		return point{x, y}
+	}
+
+	for _, seg := range g.segments {
+		switch seg.Op {
+		case sfnt.SegmentOpMoveTo:
+			pg.current = at(seg.Args[0])
+			pg.path = append(pg.path, subpath{points: []point{pg.current}, closed: true})
+		case sfnt.SegmentOpLineTo:
+			pg.lineTo(at(seg.Args[0]))
+		case sfnt.SegmentOpQuadTo:
+			p0, q, p := pg.current, at(seg.Args[0]), at(seg.Args[1])
+			pg.curveTo(
+				point{p0.x + (q.x-p0.x)*2/3, p0.y + (q.y-p0.y)*2/3},
+				point{p.x + (q.x-p.x)*2/3, p.y + (q.y-p.y)*2/3},
+				p,
+			)
+		case sfnt.SegmentOpCubeTo:
+			pg.curveTo(at(seg.Args[0]), at(seg.Args[1]), at(seg.Args[2]))
+		}
+	}
+}
+
+func clamp(v, lo, hi float64) float64 {
+	if v < lo {
+		return lo
+	}
+	if v > hi {
+		return hi
+	}
+	return v
+}
@@ -0,0 +1,267 @@
+package preview
+
+import (
+	"bytes"
+	"fmt"
+	"image"
+	"image/color"
+	"image/jpeg"
+	"math"
+
+	"github.com/pdfcpu/pdfcpu/pkg/pdfcpu/types"
+	"golang.org/x/image/draw"
+	"golang.org/x/image/math/f64"
+)
+
+// maxImageSamples bounds the decoded size of a single image XObject.
+const maxImageSamples = 64 << 20
+
+func (pg *pdfPage) drawImage(gs *graphicsState, sd *types.StreamDict) {
+	var src image.Image
+	if pg.isImageMask(sd.Dict) {
+		src = pg.decodeMask(sd, withAlpha(gs.fill, gs.fillAlpha))
+	} else {
+		src = pg.decodeImage(sd, gs.fillAlpha)
+	}
+	if src == nil {
+		return
+	}
+
+	b := src.Bounds()
+	w, h := float64(b.Dx()), float64(b.Dy())
+
+	// Image space maps the unit square, with the first row at the top.
+	m := matrix{1 / w, 0, 0, -1 / h, 0, 1}.mul(gs.ctm)
+	if det := m[0]*m[3] - m[1]*m[2]; det == 0 || math.IsNaN(det) || math.IsInf(det, 0) {
+		return
+	}
+
+	s2d := f64.Aff3{m[0], m[2], m[4], m[1], m[3], m[5]}
+	draw.ApproxBiLinear.Transform(pg.dst, s2d, src, b, draw.Over, nil)
+}
+
+func (pg *pdfPage) isImageMask(d types.Dict) bool {
+	b, ok := pg.resolve(d["ImageMask"]).(types.Boolean)
+	return ok && bool(b)
+}
+
+func (pg *pdfPage) imageSize(d types.Dict) (int, int, bool) {
+	w, okW := pg.number(d["Width"])
+	h, okH := pg.number(d["Height"])
+	if !okW || !okH || w < 1 || h < 1 || w*h > maxImageSamples {
+		return 0, 0, false
+	}
+	return int(w), int(h), true
+}
+
+func soleFilter(sd *types.StreamDict) string {
+	if len(sd.FilterPipeline) == 1 {
+		return sd.FilterPipeline[0].Name
+	}
+	return ""
+}
+
+// decodeMask paints the image mask with the fill color.
+func (pg *pdfPage) decodeMask(sd *types.StreamDict, fill color.NRGBA) image.Image {
+	w, h, ok := pg.imageSize(sd.Dict)
+	if !ok {
+		return nil
+	}
+
+	samples, ok := pg.samples(sd, w, h, 1, 1)
+	if !ok {
+		return nil
+	}
+
+	// By default sample 0 paints, a Decode array of [1 0] inverts that.
+	paint := uint16(0)
+	if decode := pg.array(sd.Dict["Decode"]); len(decode) == 2 {
+		if n, _ := pg.number(decode[0]); n == 1 {
+			paint = 1
+		}
+	}
+
+	img := image.NewNRGBA(image.Rect(0, 0, w, h))
+	for i, s := range samples {
+		if s == paint {
+			img.Pix[i*4+0] = fill.R
+			img.Pix[i*4+1] = fill.G
+			img.Pix[i*4+2] = fill.B
+			img.Pix[i*4+3] = fill.A
+		}
+	}
+
+	return img
+}
+
+func (pg *pdfPage) decodeImage(sd *types.StreamDict, alpha float64) image.Image {
+	w, h, ok := pg.imageSize(sd.Dict)
+	if !ok {
+		return nil
+	}
+
+	var img *image.NRGBA
+
+	switch soleFilter(sd) {
+	case "DCTDecode":
+		// pdfcpu leaves DCT streams encoded.
+		decoded, err := decodeJPEG(sd.Raw)
+		if err != nil {
+			return nil
+		}
+		img = toNRGBA(decoded)
+	case "JPXDecode", "JBIG2Decode":
+		return nil
+	default:
+		bpc, _ := pg.number(sd.Dict["BitsPerComponent"])
+		space := pg.parseColorSpace(sd.Dict["ColorSpace"], 0)
+		if space.components == 0 {
+			return nil
+		}
+
+		samples, ok := pg.samples(sd, w, h, space.components, int(bpc))
+		if !ok {
+			return nil
+		}
+
+		max := float64(uint32(1)<<uint(bpc) - 1)
+		if space.kind == "Indexed" {
+			max = 1
+		}
+
+		img = image.NewNRGBA(image.Rect(0, 0, w, h))
+		values := make([]float64, space.components)
+		for i := 0; i < w*h; i++ {
+			for j := range values {
+				values[j] = float64(samples[i*space.components+j]) / max
+			}
+			c := space.rgb(values)
+			img.Pix[i*4+0] = c.R
+			img.Pix[i*4+1] = c.G
+			img.Pix[i*4+2] = c.B
+			img.Pix[i*4+3] = 0xff
+		}
+	}
+
+	if img == nil {
+		return nil
+	}
+
+	if smask, ok := pg.resolve(sd.Dict["SMask"]).(types.StreamDict); ok {
+		pg.applySoftMask(img, &smask)
+	}
+
+	if alpha < 1 {
+		for i := 3; i < len(img.Pix); i += 4 {
+			img.Pix[i] = uint8(float64(img.Pix[i]) * alpha)
+		}
+	}
+
+	return img
+}
+
+// applySoftMask uses a grayscale image as the alpha channel of img.
+func (pg *pdfPage) applySoftMask(img *image.NRGBA, sd *types.StreamDict) {
+	w, h, ok := pg.imageSize(sd.Dict)
+	if !ok {
+		return
+	}
+
+	var mask *image.NRGBA
+	if soleFilter(sd) == "DCTDecode" {
+		decoded, err := decodeJPEG(sd.Raw)
+		if err != nil {
+			return
+		}
+		mask = toNRGBA(decoded)
+	} else {
+		bpc, _ := pg.number(sd.Dict["BitsPerComponent"])
+		samples, ok := pg.samples(sd, w, h, 1, int(bpc))
+		if !ok {
+			return
+		}
+		max := float64(uint32(1)<<uint(bpc) - 1)
+		mask = image.NewNRGBA(image.Rect(0, 0, w, h))
+		for i, s := range samples {
+			mask.Pix[i*4] = channel(float64(s) / max)
+		}
+	}
+
+	// Masks may have another resolution than the image they apply to.
+	ib, mb := img.Bounds(), mask.Bounds()
+	for y := 0; y < ib.Dy(); y++ {
+		my := y * mb.Dy() / ib.Dy()
+		for x := 0; x < ib.Dx(); x++ {
+			mx := x * mb.Dx() / ib.Dx()
+			img.Pix[y*img.Stride+x*4+3] = mask.Pix[my*mask.Stride+mx*4]
+		}
+	}
+}
+
+// samples decodes the stream and unpacks its samples, rows being padded to
+// whole bytes.
+func (pg *pdfPage) samples(sd *types.StreamDict, w, h, components, bpc int) ([]uint16, bool) {
+	switch bpc {
+	case 1, 2, 4, 8, 16:
+	default:
+		return nil, false
+	}
+
+	if w*h*components > maxImageSamples {
+		return nil, false
+	}
+
+	if err := decode(sd); err != nil {
+		return nil, false
+	}
+	data := sd.Content
+
+	rowBytes := (w*components*bpc + 7) / 8
+	if len(data) < rowBytes*h {
+		return nil, false
+	}
+
+	out := make([]uint16, 0, w*h*components)
+	for y := 0; y < h; y++ {
+		row := data[y*rowBytes : (y+1)*rowBytes]
+		for i := 0; i < w*components; i++ {
+			switch bpc {
+			case 8:
+				out = append(out, uint16(row[i]))
+			case 16:
+				out = append(out, uint16(row[i*2])<<8|uint16(row[i*2+1]))
+			default:
+				bit := i * bpc
+				shift := 8 - bpc - bit%8
+				out = append(out, uint16(row[bit/8]>>uint(shift))&(1<<uint(bpc)-1))
+			}
+		}
+	}
+
+	return out, true
+}
+
+// decodeJPEG checks the dimensions of a DCT stream before decoding it, since
+// the whole image is allocated up front and converted to 4 bytes per pixel.
+func decodeJPEG(data []byte) (image.Image, error) {
+	cfg, err := jpeg.DecodeConfig(bytes.NewReader(data))
+	if err != nil {
+		return nil, err
+	}
+
+	if cfg.Width < 1 || cfg.Height < 1 || cfg.Width*cfg.Height*4 > maxImageSamples {
+		return nil, fmt.Errorf("jpeg image of %dx%d exceeds the size limit", cfg.Width, cfg.Height)
+	}
+
+	return jpeg.Decode(bytes.NewReader(data))
+}
+
+func toNRGBA(src image.Image) *image.NRGBA {
+	if img, ok := src.(*image.NRGBA); ok {
+		return img
+	}
+	b := src.Bounds()
+	img := image.NewNRGBA(image.Rect(0, 0, b.Dx(), b.Dy()))
+	draw.Draw(img, img.Bounds(), src, b.Min, draw.Src)
+	return img
+}
@@ -0,0 +1,472 @@
+package preview
+
+import (
+	"image"
+	"image/color"
+	"math"
+	"time"
+
+	"github.com/pdfcpu/pdfcpu/pkg/pdfcpu/model"
+	"github.com/pdfcpu/pdfcpu/pkg/pdfcpu/types"
+	"golang.org/x/image/font/sfnt"
+	"golang.org/x/image/vector"
+)
+
+// maxFormDepth bounds nested form XObjects, which may reference each other.
+const maxFormDepth = 8
+
+// maxCoordinate keeps device coordinates in a range the rasterizer handles
+// quickly; anything further out is off the page anyway.
+const maxCoordinate = 1 << 15
+
+type point struct{ x, y float64 }
+
+type subpath struct {
+	points []point
+	closed bool
+}
+
+type graphicsState struct {
+	ctm         matrix
+	fill        color.NRGBA
+	stroke      color.NRGBA
+	fillSpace   colorSpace
+	strokeSpace colorSpace
+	fillAlpha   float64
+	strokeAlpha float64
+	lineWidth   float64
+	text        textState
+}
+
+func newGraphicsState(ctm matrix) *graphicsState {
+	return &graphicsState{
+		ctm:         ctm,
+		fill:        color.NRGBA{A: 0xff},
+		stroke:      color.NRGBA{A: 0xff},
+		fillSpace:   deviceGray,
+		strokeSpace: deviceGray,
+		fillAlpha:   1,
+		strokeAlpha: 1,
+		lineWidth:   1,
+		text:        textState{scale: 1},
+	}
+}
+
+func (gs *graphicsState) clone() *graphicsState {
+	c := *gs
+	return &c
+}
+
+// pdfPage holds the state of rendering one page into dst.
+type pdfPage struct {
+	xref          *model.XRefTable
+	dst           *image.RGBA
+	fonts         map[types.IndirectRef]*pdfFont
+	maxOperations int
+	operations    int
+	deadline      time.Time
+	// err stops the rendering, nested content streams included.
+	err error
+
+	rasterizer vector.Rasterizer
+	path       []subpath
+	current    point
+	glyphs     map[glyphKey]*glyph
+	buffer     sfnt.Buffer
+
+	// Text and text line matrices, reset by BT.
+	tm, tlm matrix
+}
+
+// run interprets a content stream with the given resources.
+func (pg *pdfPage) run(content []byte, resources types.Dict, gs *graphicsState, depth int) {
+	lex := newLexer(content)
+	var stack []*graphicsState
+	var operands []interface{}
+
+	for {
+		token := lex.next()
+		if token == nil {
+			return
+		}
+
+		op, ok := token.(pdfOperator)
+		if !ok {
+			operands = append(operands, token)
+			continue
+		}
+
+		pg.operations++
+		if pg.maxOperations > 0 && pg.operations > pg.maxOperations {
+			return
+		}
+
+		if !pg.deadline.IsZero() && time.Now().After(pg.deadline) {
+			pg.err = errRenderTimeout
+		}
+		if pg.err != nil {
+			return
+		}
+
+		switch op {
+		case "q":
+			stack = append(stack, gs.clone())
+		case "Q":
+			if len(stack) > 0 {
+				gs = stack[len(stack)-1]
+				stack = stack[:len(stack)-1]
+			}
+		case "cm":
+			if m, ok := matrixOperand(operands); ok {
+				gs.ctm = m.mul(gs.ctm)
+			}
+		case "w":
+			if n, ok := numberAt(operands, 0); ok {
+				gs.lineWidth = n
+			}
+		case "gs":
+			pg.setExtGState(gs, resources, nameAt(operands, 0))
+
+		case "g", "G", "rg", "RG", "k", "K", "sc", "SC", "scn", "SCN":
+			pg.setColor(gs, string(op), operands)
+		case "cs":
+			gs.fillSpace = pg.colorSpace(resources, nameAt(operands, 0))
+			gs.fill = gs.fillSpace.initial()
+		case "CS":
+			gs.strokeSpace = pg.colorSpace(resources, nameAt(operands, 0))
+			gs.stroke = gs.strokeSpace.initial()
+
+		case "m", "l", "c", "v", "y", "h", "re":
+			pg.buildPath(gs, string(op), operands)
+		case "f", "F", "f*", "S", "s", "B", "B*", "b", "b*", "n":
+			pg.paintPath(gs, string(op))
+
+		case "Do":
+			pg.drawXObject(gs, resources, nameAt(operands, 0), depth)
+		case "BI":
+			// Inline images are small by definition and skipped.
+			for {
+				t := lex.next()
+				if t == nil || t == pdfOperator("ID") {
+					break
+				}
+			}
+			lex.skipInlineImage()
+
+		default:
+			pg.textOperator(gs, resources, string(op), operands)
+		}
+
+		operands = operands[:0]
+	}
+}
+
+func numberAt(operands []interface{}, i int) (float64, bool) {
+	if i < 0 || i >= len(operands) {
+		return 0, false
+	}
+	n, ok := operands[i].(float64)
+	return n, ok
+}
+
+func nameAt(operands []interface{}, i int) string {
+	if i < 0 || i >= len(operands) {
+		return ""
+	}
+	n, _ := operands[i].(pdfName)
+	return string(n)
+}
+
+// numbers returns the trailing numeric operands.
+func numbers(operands []interface{}) []float64 {
+	var out []float64
+	for _, o := range operands {
+		if n, ok := o.(float64); ok {
+			out = append(out, n)
+		}
+	}
+	return out
+}
+
+func matrixOperand(operands []interface{}) (matrix, bool) {
+	n := numbers(operands)
+	if len(n) != 6 {
+		return identity, false
+	}
+	return matrix{n[0], n[1], n[2], n[3], n[4], n[5]}, true
+}
+
+func (pg *pdfPage) setExtGState(gs *graphicsState, resources types.Dict, name string) {
+	d := pg.dict(pg.resource(resources, "ExtGState", name))
+	if d == nil {
+		return
+	}
+	if n, ok := pg.number(d["LW"]); ok {
+		gs.lineWidth = n
+	}
+	if n, ok := pg.number(d["ca"]); ok {
+		gs.fillAlpha = clamp01(n)
+	}
+	if n, ok := pg.number(d["CA"]); ok {
+		gs.strokeAlpha = clamp01(n)
+	}
+}
+
+func (pg *pdfPage) buildPath(gs *graphicsState, op string, operands []interface{}) {
+	n := numbers(operands)
+	at := func(i int) point {
+		x, y := gs.ctm.apply(n[i], n[i+1])
+		return point{x, y}
+	}
+
+	switch op {
+	case "m":
+		if len(n) < 2 {
+			return
+		}
+		pg.current = at(0)
+		pg.path = append(pg.path, subpath{points: []point{pg.current}})
+	case "l":
+		if len(n) < 2 {
+			return
+		}
+		pg.lineTo(at(0))
+	case "c":
+		if len(n) < 6 {
+			return
+		}
+		pg.curveTo(at(0), at(2), at(4))
+	case "v":
+		if len(n) < 4 {
+			return
+		}
+		pg.curveTo(pg.current, at(0), at(2))
+	case "y":
+		if len(n) < 4 {
+			return
+		}
+		p := at(2)
+		pg.curveTo(at(0), p, p)
+	case "h":
+		if len(pg.path) > 0 {
+			last := &pg.path[len(pg.path)-1]
+			last.closed = true
+			pg.current = last.points[0]
+		}
+	case "re":
+		if len(n) < 4 {
+			return
+		}
+		x, y, w, h := n[0], n[1], n[2], n[3]
+		corners := [][2]float64{{x, y}, {x + w, y}, {x + w, y + h}, {x, y + h}}
+		sp := subpath{closed: true}
+		for _, c := range corners {
+			px, py := gs.ctm.apply(c[0], c[1])
+			sp.points = append(sp.points, point{px, py})
+		}
+		pg.path = append(pg.path, sp)
+		pg.current = sp.points[0]
+	}
+}
+
+func (pg *pdfPage) lineTo(p point) {
+	if len(pg.path) == 0 {
+		pg.path = append(pg.path, subpath{points: []point{pg.current}})
+	}
+	last := &pg.path[len(pg.path)-1]
+	last.points = append(last.points, p)
+	pg.current = p
+}
+
+// curveTo flattens a cubic Bézier curve from the current point.
+func (pg *pdfPage) curveTo(c1, c2, p point) {
+	p0 := pg.current
+	length := math.Hypot(c1.x-p0.x, c1.y-p0.y) + math.Hypot(c2.x-c1.x, c2.y-c1.y) + math.Hypot(p.x-c2.x, p.y-c2.y)
+
+	steps := int(math.Ceil(length / 4))
+	if steps < 1 {
+		steps = 1
+	}
+	if steps > 64 {
+		steps = 64
+	}
+
+	for i := 1; i <= steps; i++ {
+		t := float64(i) / float64(steps)
+		mt := 1 - t
+		a, b, c, d := mt*mt*mt, 3*mt*mt*t, 3*mt*t*t, t*t*t
+		pg.lineTo(point{
+			a*p0.x + b*c1.x + c*c2.x + d*p.x,
+			a*p0.y + b*c1.y + c*c2.y + d*p.y,
+		})
+	}
+}
+
+func (pg *pdfPage) paintPath(gs *graphicsState, op string) {
+	path := pg.path
+	pg.path = nil
+
+	if op == "s" || op == "b" || op == "b*" {
+		for i := range path {
+			path[i].closed = true
+		}
+	}
+
+	switch op {
+	case "f", "F", "f*", "B", "B*", "b", "b*":
+		if !gs.fillSpace.pattern {
+			pg.fill(path, withAlpha(gs.fill, gs.fillAlpha))
+		}
+	}
+
+	switch op {
+	case "S", "s", "B", "B*", "b", "b*":
+		pg.stroke(path, gs.lineWidth*gs.ctm.scale(), withAlpha(gs.stroke, gs.strokeAlpha))
+	}
+}
+
+// fill paints the area of the subpaths with the nonzero winding rule. The
+// even-odd rule only differs for self intersecting paths, rare enough in
+// documents to not matter for a thumbnail.
+func (pg *pdfPage) fill(path []subpath, c color.NRGBA) {
+	if c.A == 0 || len(path) == 0 {
+		return
+	}
+
+	minX, minY := math.Inf(1), math.Inf(1)
+	maxX, maxY := math.Inf(-1), math.Inf(-1)
+	for _, sp := range path {
+		for _, p := range sp.points {
+			minX, minY = math.Min(minX, p.x), math.Min(minY, p.y)
+			maxX, maxY = math.Max(maxX, p.x), math.Max(maxY, p.y)
+		}
+	}
+
+	bounds := image.Rect(
+		int(math.Floor(clampCoordinate(minX))), int(math.Floor(clampCoordinate(minY))),
+		int(math.Ceil(clampCoordinate(maxX))), int(math.Ceil(clampCoordinate(maxY))),
+	).Intersect(pg.dst.Bounds())
+	if bounds.Empty() {
+		return
+	}
+
+	ox, oy := float64(bounds.Min.X), float64(bounds.Min.Y)
+	z := &pg.rasterizer
+	z.Reset(bounds.Dx(), bounds.Dy())
+
+	for _, sp := range path {
+		if len(sp.points) < 2 {
+			continue
+		}
+		for i, p := range sp.points {
+			x := float32(clampCoordinate(p.x) - ox)
+			y := float32(clampCoordinate(p.y) - oy)
+			if i == 0 {
+				z.MoveTo(x, y)
+			} else {
+				z.LineTo(x, y)
+			}
+		}
+		z.ClosePath()
+	}
+
+	z.Draw(pg.dst, bounds, image.NewUniform(c), image.Point{})
+}
+
+// stroke paints every segment as a quad of the line width. Joins and caps are
+// approximated by extending segments by half the width.
+func (pg *pdfPage) stroke(path []subpath, width float64, c color.NRGBA) {
+	if c.A == 0 {
+		return
+	}
+	if width < 1 {
+		width = 1
+	}
+	half := width / 2
+
+	var quads []subpath
+	for _, sp := range path {
+		points := sp.points
+		if sp.closed && len(points) > 1 {
+			points = append(points[:len(points):len(points)], points[0])
+		}
+
+		for i := 1; i < len(points); i++ {
+			a, b := points[i-1], points[i]
+			dx, dy := b.x-a.x, b.y-a.y
+			length := math.Hypot(dx, dy)
+			if length == 0 {
+				continue
+			}
+
+			ux, uy := dx/length*half, dy/length*half
+			nx, ny := -uy, ux
+			a = point{a.x - ux, a.y - uy}
+			b = point{b.x + ux, b.y + uy}
+
+			quads = append(quads, subpath{points: []point{
+				{a.x + nx, a.y + ny},
+				{b.x + nx, b.y + ny},
+				{b.x - nx, b.y - ny},
+				{a.x - nx, a.y - ny},
+			}})
+		}
+	}
+
+	pg.fill(quads, c)
+}
+
+func (pg *pdfPage) drawXObject(gs *graphicsState, resources types.Dict, name string, depth int) {
+	o := pg.resource(resources, "XObject", name)
+	sd, ok := pg.resolve(o).(types.StreamDict)
+	if !ok {
+		return
+	}
+
+	switch pg.name(sd.Dict["Subtype"]) {
+	case "Image":
+		pg.drawImage(gs, &sd)
+	case "Form":
+		if depth >= maxFormDepth {
+			return
+		}
+		if err := decode(&sd); err != nil {
+			return
+		}
+
+		form := gs.clone()
+		if a := pg.array(sd.Dict["Matrix"]); len(a) == 6 {
+			var m matrix
+			for i := range m {
+				m[i], _ = pg.number(a[i])
+			}
+			form.ctm = m.mul(gs.ctm)
+		}
+
+		formResources := pg.dict(sd.Dict["Resources"])
+		if formResources == nil {
+			formResources = resources
+		}
+
+		path := pg.path
+		pg.path = nil
+		pg.run(sd.Content, formResources, form, depth+1)
+		pg.path = path
+	}
+}
+
+func clamp01(v float64) float64 {
+	return math.Max(0, math.Min(1, v))
+}
+
+func clampCoordinate(v float64) float64 {
+	if math.IsNaN(v) {
+		return 0
+	}
+	return math.Max(-maxCoordinate, math.Min(maxCoordinate, v))
+}
+
+func withAlpha(c color.NRGBA, alpha float64) color.NRGBA {
+	c.A = uint8(float64(c.A) * clamp01(alpha))
+	return c
+}
@@ -0,0 +1,279 @@
+package preview
+
+import (
+	"bytes"
+	"strconv"
+)
+
+// Content stream operands. Dictionaries are only skipped, they carry marked
+// content properties that don't affect rendering.
+type (
+	pdfName     string
+	pdfString   []byte
+	pdfOperator string
+	pdfArray    []interface{}
+	pdfDict     struct{}
+	pdfNull     struct{}
+)
+
+// lexer tokenizes PDF content streams and CMaps.
+type lexer struct {
+	data []byte
+	pos  int
+}
+
+func newLexer(data []byte) *lexer {
+	return &lexer{data: data}
+}
+
+func isWhitespace(c byte) bool {
+	return c == ' ' || c == '\n' || c == '\r' || c == '\t' || c == '\f' || c == 0
+}
+
+func isDelimiter(c byte) bool {
+	switch c {
+	case '(', ')', '<', '>', '[', ']', '{', '}', '/', '%':
+		return true
+	}
+	return false
+}
+
+func (l *lexer) skipSpace() {
+	for l.pos < len(l.data) {
+		c := l.data[l.pos]
+		if isWhitespace(c) {
+			l.pos++
+			continue
+		}
+		if c == '%' {
+			for l.pos < len(l.data) && l.data[l.pos] != '\n' && l.data[l.pos] != '\r' {
+				l.pos++
+			}
+			continue
+		}
+		return
+	}
+}
+
+// next returns the next token, or nil at the end of the data. Unbalanced
+// closing delimiters are returned as operators so the caller can stop on them.
+func (l *lexer) next() interface{} {
+	l.skipSpace()
+	if l.pos >= len(l.data) {
+		return nil
+	}
+
+	c := l.data[l.pos]
+
+	switch {
+	case c == '/':
+		l.pos++
+		return pdfName(l.regular(true))
+	case c == '(':
+		l.pos++
+		return l.literalString()
+	case c == '<' && l.peek(1) == '<':
+		l.pos += 2
+		l.skipDict()
+		return pdfDict{}
+	case c == '<':
+		l.pos++
+		return l.hexString()
+	case c == '[':
+		l.pos++
+		var array pdfArray
+		for {
+			token := l.next()
+			if token == nil || token == pdfOperator("]") {
+				return array
+			}
+			array = append(array, token)
+		}
+	case c == ']' || c == '>' || c == ')' || c == '{' || c == '}':
+		l.pos++
+		return pdfOperator(string(c))
+	}
+
+	word := l.regular(false)
+	if word == "" {
+		l.pos++
+		return l.next()
+	}
+
+	if n, err := strconv.ParseFloat(word, 64); err == nil {
+		return n
+	}
+
+	switch word {
+	case "true":
+		return true
+	case "false":
+		return false
+	case "null":
+		return pdfNull{}
+	}
+
+	return pdfOperator(word)
+}
+
+func (l *lexer) peek(offset int) byte {
+	if l.pos+offset < len(l.data) {
+		return l.data[l.pos+offset]
+	}
+	return 0
+}
+
+// regular reads a run of regular characters, decoding #xx escapes in names.
+func (l *lexer) regular(isName bool) string {
+	start := l.pos
+	for l.pos < len(l.data) && !isWhitespace(l.data[l.pos]) && !isDelimiter(l.data[l.pos]) {
+		l.pos++
+	}
+
+	word := l.data[start:l.pos]
+	if !isName || bytes.IndexByte(word, '#') < 0 {
+		return string(word)
+	}
+
+	var out []byte
+	for i := 0; i < len(word); i++ {
+		if word[i] == '#' && i+2 < len(word) {
+			if v, err := strconv.ParseUint(string(word[i+1:i+3]), 16, 8); err == nil {
+				out = append(out, byte(v))
+				i += 2
+				continue
+			}
+		}
+		out = append(out, word[i])
+	}
+	return string(out)
+}
+
+func (l *lexer) literalString() pdfString {
+	var out []byte
+	depth := 1
+
+	for l.pos < len(l.data) {
+		c := l.data[l.pos]
+		l.pos++
+
+		switch c {
+		case '(':
+			depth++
+		case ')':
+			depth--
+			if depth == 0 {
+				return out
+			}
+		case '\\':
+			if l.pos >= len(l.data) {
+				return out
+			}
+			e := l.data[l.pos]
+			l.pos++
+
+			switch e {
+			case 'n':
+				c = '\n'
+			case 'r':
+				c = '\r'
+			case 't':
+				c = '\t'
+			case 'b':
+				c = '\b'
+			case 'f':
+				c = '\f'
+			case '\r':
+				if l.pos < len(l.data) && l.data[l.pos] == '\n' {
+					l.pos++
+				}
+				continue
+			case '\n':
+				continue
+			default:
+				if e >= '0' && e <= '7' {
+					v := int(e - '0')
+					for i := 0; i < 2 && l.pos < len(l.data) && l.data[l.pos] >= '0' && l.data[l.pos] <= '7'; i++ {
+						v = v*8 + int(l.data[l.pos]-'0')
+						l.pos++
+					}
+					c = byte(v)
+				} else {
+					c = e
+				}
+			}
+		}
+
+		out = append(out, c)
+	}
+
+	return out
+}
+
+func (l *lexer) hexString() pdfString {
+	var out []byte
+	var digits []byte
+
+	for l.pos < len(l.data) {
+		c := l.data[l.pos]
+		l.pos++
+
+		if c == '>' {
+			break
+		}
+		if isWhitespace(c) {
+			continue
+		}
+		digits = append(digits, c)
+	}
+
+	if len(digits)%2 == 1 {
+		digits = append(digits, '0')
+	}
+
+	for i := 0; i+1 < len(digits); i += 2 {
+		v, err := strconv.ParseUint(string(digits[i:i+2]), 16, 8)
+		if err != nil {
+			continue
+		}
+		out = append(out, byte(v))
+	}
+
+	return out
+}
+
+func (l *lexer) skipDict() {
+	for {
+		l.skipSpace()
+		if l.pos >= len(l.data) {
+			return
+		}
+		if l.data[l.pos] == '>' && l.peek(1) == '>' {
+			l.pos += 2
+			return
+		}
+		if l.next() == nil {
+			return
+		}
+	}
+}
+
+// skipInlineImage moves past the data of an inline image, which starts right
+// after the ID operator and ends with EI.
+func (l *lexer) skipInlineImage() {
+	if l.pos < len(l.data) && isWhitespace(l.data[l.pos]) {
+		l.pos++
+	}
+
+	for l.pos+1 < len(l.data) {
+		if l.data[l.pos] == 'E' && l.data[l.pos+1] == 'I' &&
+			(l.pos == 0 || isWhitespace(l.data[l.pos-1])) &&
+			(l.pos+2 >= len(l.data) || isWhitespace(l.data[l.pos+2])) {
+			l.pos += 2
+			return
+		}
+		l.pos++
+	}
+
+	l.pos = len(l.data)
+}
@@ -0,0 +1,118 @@
+package preview
+
+import (
+	"errors"
+	"image"
+	"os"
+	"path/filepath"
+	"runtime"
+	"testing"
+	"time"
+)
+
+func renderFixture(t *testing.T, name string) (image.Image, error) {
+	t.Helper()
+
+	renderer := NewPDFRenderer()
+	renderer.Width = 200
+	return renderFixtureWith(t, renderer, name)
+}
+
+func renderFixtureWith(t *testing.T, renderer *PDFRenderer, name string) (image.Image, error) {
+	t.Helper()
+
+	f, err := os.Open(filepath.Join("testdata", name))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+
+	return renderer.Render(f)
+}
+
+func TestPDFRendererRendersFirstPage(t *testing.T) {
+	img, err := renderFixture(t, "valid.pdf")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if b := img.Bounds(); b.Dx() != 200 || b.Dy() != 100 {
+		t.Fatalf("got a %dx%d image, want 200x100", b.Dx(), b.Dy())
+	}
+
+	// The left half is a red rectangle, the right half a blue JPEG.
+	tests := []struct {
+		name    string
+		x, y    int
+		r, g, b uint32
+	}{
+		{"rectangle", 50, 50, 0xffff, 0, 0},
+		{"image", 150, 50, 0, 0, 0xffff},
+	}
+
+	for _, tt := range tests {
+		r, g, b, _ := img.At(tt.x, tt.y).RGBA()
+		if !near(r, tt.r) || !near(g, tt.g) || !near(b, tt.b) {
+			t.Errorf("%s: got rgb(%d, %d, %d), want rgb(%d, %d, %d)", tt.name, r>>8, g>>8, b>>8, tt.r>>8, tt.g>>8, tt.b>>8)
+		}
+	}
+}
+
+func TestPDFRendererRejectsMalformedFiles(t *testing.T) {
+	for _, name := range []string{"truncated.pdf", "garbage.pdf", "no-pages.pdf"} {
+		t.Run(name, func(t *testing.T) {
+			if _, err := renderFixture(t, name); err == nil {
+				t.Fatal("Render succeeded, want an error")
+			}
+		})
+	}
+}
+
+func TestPDFRendererBoundsDecodedStreams(t *testing.T) {
+	// The content stream inflates from 80KB to 80MB.
+	if _, err := renderFixture(t, "flate-bomb.pdf"); err == nil {
+		t.Fatal("Render succeeded, want an error")
+	}
+}
+
+func TestPDFRendererSkipsOversizedImages(t *testing.T) {
+	// The JPEG header claims 30000x30000 pixels, the image is skipped before
+	// it is allocated and the page stays blank.
+	var before, after runtime.MemStats
+	runtime.ReadMemStats(&before)
+
+	img, err := renderFixture(t, "huge-jpeg.pdf")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	runtime.ReadMemStats(&after)
+	if allocated := after.TotalAlloc - before.TotalAlloc; allocated > maxImageSamples {
+		t.Errorf("allocated %d bytes rendering the page", allocated)
+	}
+
+	r, g, b, _ := img.At(100, 50).RGBA()
+	if r != 0xffff || g != 0xffff || b != 0xffff {
+		t.Fatalf("got rgb(%d, %d, %d), want a white page", r>>8, g>>8, b>>8)
+	}
+}
+
+func TestPDFRendererTimesOut(t *testing.T) {
+	// The page fills itself 500000 times, which takes minutes at full size.
+	renderer := NewPDFRenderer()
+	renderer.Timeout = 100 * time.Millisecond
+
+	start := time.Now()
+	_, err := renderFixtureWith(t, renderer, "slow-fill.pdf")
+	if !errors.Is(err, errRenderTimeout) {
+		t.Fatalf("got %v, want errRenderTimeout", err)
+	}
+	if elapsed := time.Since(start); elapsed > 5*time.Second {
+		t.Fatalf("rendering stopped after %s", elapsed)
+	}
+}
+
+func near(got, want uint32) bool {
+	d := int(got) - int(want)
+	return d > -0x1000 && d < 0x1000
+}
@@ -0,0 +1,64 @@
+// Package preview draws still images of files that are not images
+// themselves, such as the first page of a PDF or a frame of a video. They are
+// the source of the small, medium and large variants generated for those files.
+package preview
+
+import (
+	"image"
+	"io"
+	"log"
+	"strings"
+	"sync"
+)
+
+// Renderer draws a still image of r.
+type Renderer interface {
+	Render(r io.ReadSeeker) (image.Image, error)
+}
+
+// Describer is implemented by renderers that also read the metadata of the
+// files they render.
+type Describer interface {
+	Describe(r io.ReadSeeker) (*MediaInfo, error)
+}
+
+// Renderers maps content types, or a whole top-level type such as "video/*",
+// to the renderer of their previews. Files of other types get no variants.
+type Renderers map[string]Renderer
+
+// For returns the renderer of a content type, if any.
+func (r Renderers) For(mimeType string) (Renderer, bool) {
+	renderer, ok := r[mimeType]
+	if !ok {
+		if i := strings.IndexByte(mimeType, '/'); i > 0 {
+			renderer, ok = r[mimeType[:i]+"/*"]
+		}
+	}
+	return renderer, ok && renderer != nil
+}
+
+var (
+	ffmpeg     *FFmpeg
+	ffmpegOnce sync.Once
+)
+
+// Default returns the renderers available on this host. Videos are only
+// previewed when ffmpeg and ffprobe are installed.
+func Default() Renderers {
+	renderers := Renderers{
+		"application/pdf": NewPDFRenderer(),
+	}
+
+	ffmpegOnce.Do(func() {
+		var err error
+		if ffmpeg, err = NewFFmpeg(); err != nil {
+			log.Printf("Skipping video previews: %v\n", err)
+		}
+	})
+
+	if ffmpeg != nil {
+		renderers["video/*"] = NewVideoRenderer(ffmpeg)
+	}
+
+	return renderers
+}
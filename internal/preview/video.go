@@ -0,0 +1,56 @@
+package preview
+
+import (
+	"fmt"
+	"image"
+	"io"
+	"time"
+
+	"nova-cdn/internal/config"
+)
+
+// MediaInfo is the metadata recorded for audio and video files.
+type MediaInfo struct {
+	Duration time.Duration
+	Width    int
+	Height   int
+	Codec    string
+	// Bitrate in bits per second.
+	Bitrate uint64
+}
+
+// Prober reads the metadata of videos and extracts their frames. FFmpeg is the
+// default implementation.
+type Prober interface {
+	Probe(r io.ReadSeeker) (*MediaInfo, error)
+	Frame(r io.ReadSeeker, at time.Duration) (image.Image, error)
+}
+
+// VideoRenderer uses a frame of the video as its poster.
+type VideoRenderer struct {
+	Prober Prober
+	// PosterOffset is the time of the frame used, skipping the black or fade
+	// in frames videos often start with. Shorter videos use their first frame.
+	PosterOffset time.Duration
+}
+
+func NewVideoRenderer(prober Prober) *VideoRenderer {
+	return &VideoRenderer{Prober: prober, PosterOffset: config.VideoPosterOffset}
+}
+
+func (v *VideoRenderer) Render(r io.ReadSeeker) (image.Image, error) {
+	img, err := v.Prober.Frame(r, v.PosterOffset)
+	if err != nil && v.PosterOffset > 0 {
+		img, err = v.Prober.Frame(r, 0)
+	}
+
+	if err != nil {
+		return nil, fmt.Errorf("failed to extract poster frame: %w", err)
+	}
+
+	return img, nil
+}
+
+func (v *VideoRenderer) Describe(r io.ReadSeeker) (*MediaInfo, error) {
+	return v.Prober.Probe(r)
+}
@@ -0,0 +1,66 @@
+package preview
+
+import (
+	"errors"
+	"image"
+	"io"
+	"strings"
+	"testing"
+	"time"
+)
+
+// fakeProber returns a blank frame for the offsets in frames and fails for
+// any other, recording every offset it was asked for.
+type fakeProber struct {
+	frames    map[time.Duration]bool
+	requested []time.Duration
+}
+
+func (p *fakeProber) Probe(r io.ReadSeeker) (*MediaInfo, error) {
+	return &MediaInfo{Duration: time.Second}, nil
+}
+
+func (p *fakeProber) Frame(r io.ReadSeeker, at time.Duration) (image.Image, error) {
+	p.requested = append(p.requested, at)
+	if !p.frames[at] {
+		return nil, errors.New("no frame at this offset")
+	}
+	return image.NewRGBA(image.Rect(0, 0, 4, 4)), nil
+}
+
+func TestVideoRendererPosterFrame(t *testing.T) {
+	tests := []struct {
+		name          string
+		frames        map[time.Duration]bool
+		wantRequested []time.Duration
+		wantErr       bool
+	}{
+		{"offset", map[time.Duration]bool{0: true, 3 * time.Second: true}, []time.Duration{3 * time.Second}, false},
+		{"shorter than the offset", map[time.Duration]bool{0: true}, []time.Duration{3 * time.Second, 0}, false},
+		{"no frame", map[time.Duration]bool{}, []time.Duration{3 * time.Second, 0}, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			prober := &fakeProber{frames: tt.frames}
+			renderer := &VideoRenderer{Prober: prober, PosterOffset: 3 * time.Second}
+
+			img, err := renderer.Render(strings.NewReader("video"))
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("got error %v, want one: %v", err, tt.wantErr)
+			}
+			if !tt.wantErr && img == nil {
+				t.Fatal("got no poster")
+			}
+
+			if len(prober.requested) != len(tt.wantRequested) {
+				t.Fatalf("requested frames at %v, want %v", prober.requested, tt.wantRequested)
+			}
+			for i, at := range tt.wantRequested {
+				if prober.requested[i] != at {
+					t.Fatalf("requested frames at %v, want %v", prober.requested, tt.wantRequested)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,80 @@
+package reconcile
+
+import (
+	"errors"
+	"fmt"
+	"nova-cdn/internal/storage"
+)
+
+// MisplacedFile is the file of a private row still stored on the public disk,
+// where private uploads were written before they had a disk of their own.
+type MisplacedFile struct {
+	Key  string `json:"key"`
+	Size int64  `json:"size"`
+}
+
+// FindMisplacedPrivate lists the files of private rows, trashed ones included,
+// that are missing from the private disk but found on the public one.
+func (r *Reconciler) FindMisplacedPrivate() ([]MisplacedFile, error) {
+	rows, err := r.GalleryRepo.FindAllFiles()
+	if err != nil {
+		return nil, fmt.Errorf("failed to load galleries: %w", err)
+	}
+
+	files := []MisplacedFile{}
+	seen := map[string]bool{}
+
+	for _, row := range rows {
+		if !row.IsPrivate || seen[row.FilePath] {
+			continue
+		}
+		seen[row.FilePath] = true
+
+		if _, err := r.Disks.Private.Stat(row.FilePath); !errors.Is(err, storage.ErrNotFound) {
+			if err != nil {
+				return nil, fmt.Errorf("failed to check %s: %w", row.FilePath, err)
+			}
+			continue
+		}
+
+		obj, err := r.Disks.Public.Stat(row.FilePath)
+		if errors.Is(err, storage.ErrNotFound) {
+			// Missing from both disks, Scan reports it as broken.
+			continue
+		}
+		if err != nil {
+			return nil, fmt.Errorf("failed to check %s: %w", row.FilePath, err)
+		}
+
+		files = append(files, MisplacedFile{Key: row.FilePath, Size: obj.Size})
+	}
+
+	return files, nil
+}
+
+// MovePrivateFiles copies the misplaced files to the private disk, then
+// deletes their public copy unless a public row shares it. It returns how many
+// were moved.
+func (r *Reconciler) MovePrivateFiles(files []MisplacedFile) (int, error) {
+	moved := 0
+
+	for _, file := range files {
+		if err := copyFile(r.Disks.Public, r.Disks.Private, file.Key, file.Key); err != nil {
+			return moved, fmt.Errorf("failed to move %s: %w", file.Key, err)
+		}
+
+		count, err := r.GalleryRepo.CountByFilePath(file.Key, false)
+		if err != nil {
+			return moved, err
+		}
+
+		if count == 0 {
+			if err := r.Disks.Public.Delete(file.Key); err != nil {
+				return moved, fmt.Errorf("failed to delete the public copy of %s: %w", file.Key, err)
+			}
+		}
+		moved++
+	}
+
+	return moved, nil
+}
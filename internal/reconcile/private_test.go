@@ -0,0 +1,88 @@
+package reconcile
+
+import (
+	"errors"
+	"strings"
+	"testing"
+
+	"nova-cdn/internal/config"
+	"nova-cdn/internal/migrations"
+	"nova-cdn/internal/models"
+	"nova-cdn/internal/repositories"
+	"nova-cdn/internal/storage"
+
+	"github.com/glebarez/sqlite"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+)
+
+func TestMovePrivateFiles(t *testing.T) {
+	db, err := gorm.Open(sqlite.Open(":memory:"), &gorm.Config{Logger: logger.Default.LogMode(logger.Silent)})
+	if err != nil {
+		t.Fatal(err)
+	}
+	sqlDB, _ := db.DB()
+	sqlDB.SetMaxOpenConns(1)
+
+	migrator, err := migrations.New(db)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := migrator.Up(); err != nil {
+		t.Fatal(err)
+	}
+
+	// Gallery rows build their URL from the configured disks when loaded.
+	config.FilesystemDisk = "local"
+	config.FilesystemRoot = t.TempDir()
+	config.FilesystemPrivateRoot = t.TempDir()
+	storage.ConnectStorage()
+	disks := storage.GetStorage()
+
+	rows := []*models.Gallery{
+		{FilePath: "images/gallery/legacy.jpg", IsPrivate: true, Size: "original", GroupCode: "GL-1"},
+		{FilePath: "images/gallery/moved.jpg", IsPrivate: true, Size: "original", GroupCode: "GL-2"},
+		{FilePath: "images/gallery/public.jpg", IsPrivate: false, Size: "original", GroupCode: "GL-3"},
+	}
+	if err := repositories.NewGalleryRepository(db).CreateMany(rows); err != nil {
+		t.Fatal(err)
+	}
+
+	put := func(disk storage.Storage, key string) {
+		if err := disk.Put(key, strings.NewReader(key), int64(len(key)), "image/jpeg"); err != nil {
+			t.Fatal(err)
+		}
+	}
+	put(disks.Public, "images/gallery/legacy.jpg")
+	put(disks.Private, "images/gallery/moved.jpg")
+	put(disks.Public, "images/gallery/public.jpg")
+
+	reconciler := &Reconciler{GalleryRepo: repositories.NewGalleryRepository(db), Disks: disks}
+
+	files, err := reconciler.FindMisplacedPrivate()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(files) != 1 || files[0].Key != "images/gallery/legacy.jpg" {
+		t.Fatalf("got %+v, want only the legacy file", files)
+	}
+
+	moved, err := reconciler.MovePrivateFiles(files)
+	if err != nil || moved != 1 {
+		t.Fatalf("moved %d files: %v", moved, err)
+	}
+
+	if _, err := disks.Private.Stat("images/gallery/legacy.jpg"); err != nil {
+		t.Errorf("legacy file is not on the private disk: %v", err)
+	}
+	if _, err := disks.Public.Stat("images/gallery/legacy.jpg"); !errors.Is(err, storage.ErrNotFound) {
+		t.Errorf("legacy file is still public: %v", err)
+	}
+	if _, err := disks.Public.Stat("images/gallery/public.jpg"); err != nil {
+		t.Errorf("public file was touched: %v", err)
+	}
+
+	if files, err := reconciler.FindMisplacedPrivate(); err != nil || len(files) != 0 {
+		t.Errorf("got %+v, %v after moving, want nothing", files, err)
+	}
+}
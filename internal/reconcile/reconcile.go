@@ -0,0 +1,173 @@
+package reconcile
+
+import (
+	"fmt"
+	"nova-cdn/internal/repositories"
+	"nova-cdn/internal/storage"
+	"path"
+	"time"
+)
+
+// Prefixes are the parts of each disk that belong to galleries.
+var Prefixes = []string{"images/", "files/"}
+
+// OrphanFile is a stored file no gallery row points to.
+type OrphanFile struct {
+	Disk    string    `json:"disk"`
+	Key     string    `json:"key"`
+	Size    int64     `json:"size"`
+	ModTime time.Time `json:"mod_time"`
+}
+
+// BrokenRow is a gallery row whose file is missing from its disk.
+type BrokenRow struct {
+	ID        uint   `json:"id"`
+	Disk      string `json:"disk"`
+	GroupCode string `json:"group_code"`
+	Size      string `json:"size"`
+	FilePath  string `json:"file_path"`
+	Trashed   bool   `json:"trashed"`
+}
+
+type Report struct {
+	ScannedFiles int          `json:"scanned_files"`
+	ScannedRows  int          `json:"scanned_rows"`
+	OrphanFiles  []OrphanFile `json:"orphan_files"`
+	BrokenRows   []BrokenRow  `json:"broken_rows"`
+}
+
+type Reconciler struct {
+	GalleryRepo *repositories.GalleryRepository
+	Disks       *storage.Disks
+	Quarantine  storage.Storage
+	// MinAge keeps files younger than this out of the report, since an upload
+	// writes its file before its row is committed.
+	MinAge time.Duration
+}
+
+// Scan compares the files under Prefixes on both disks with every gallery
+// row, trashed ones included.
+func (r *Reconciler) Scan() (*Report, error) {
+	report := &Report{OrphanFiles: []OrphanFile{}, BrokenRows: []BrokenRow{}}
+
+	rows, err := r.GalleryRepo.FindAllFiles()
+	if err != nil {
+		return nil, fmt.Errorf("failed to load galleries: %w", err)
+	}
+	report.ScannedRows = len(rows)
+
+	referenced := map[string]map[string]bool{"public": {}, "private": {}}
+	for _, row := range rows {
+		referenced[diskName(row.IsPrivate)][row.FilePath] = true
+	}
+
+	stored := map[string]map[string]bool{"public": {}, "private": {}}
+	cutoff := time.Now().Add(-r.MinAge)
+
+	for _, isPrivate := range []bool{false, true} {
+		name := diskName(isPrivate)
+
+		var objects []storage.Object
+		for _, prefix := range Prefixes {
+			listed, err := r.Disks.For(isPrivate).List(prefix)
+			if err != nil {
+				return nil, fmt.Errorf("failed to list %s disk: %w", name, err)
+			}
+			objects = append(objects, listed...)
+		}
+		report.ScannedFiles += len(objects)
+
+		for _, obj := range objects {
+			stored[name][obj.Key] = true
+
+			if !referenced[name][obj.Key] && obj.ModTime.Before(cutoff) {
+				report.OrphanFiles = append(report.OrphanFiles, OrphanFile{
+					Disk:    name,
+					Key:     obj.Key,
+					Size:    obj.Size,
+					ModTime: obj.ModTime,
+				})
+			}
+		}
+	}
+
+	for _, row := range rows {
+		name := diskName(row.IsPrivate)
+		if stored[name][row.FilePath] {
+			continue
+		}
+
+		report.BrokenRows = append(report.BrokenRows, BrokenRow{
+			ID:        row.ID,
+			Disk:      name,
+			GroupCode: row.GroupCode,
+			Size:      row.Size,
+			FilePath:  row.FilePath,
+			Trashed:   row.DeletedAt.Valid,
+		})
+	}
+
+	return report, nil
+}
+
+// QuarantineOrphans moves the orphan files of the report to the quarantine
+// disk, under <disk>/<key>, and returns how many were moved.
+func (r *Reconciler) QuarantineOrphans(report *Report) (int, error) {
+	moved := 0
+
+	for _, orphan := range report.OrphanFiles {
+		disk := r.Disks.For(orphan.Disk == "private")
+
+		if err := r.move(disk, orphan.Key, path.Join(orphan.Disk, orphan.Key)); err != nil {
+			return moved, fmt.Errorf("failed to quarantine %s: %w", orphan.Key, err)
+		}
+		moved++
+	}
+
+	return moved, nil
+}
+
+// MarkBrokenRows flags the broken rows of the report so they can be found and
+// fixed later; they are not deleted.
+func (r *Reconciler) MarkBrokenRows(report *Report) (int64, error) {
+	ids := make([]uint, 0, len(report.BrokenRows))
+	for _, row := range report.BrokenRows {
+		ids = append(ids, row.ID)
+	}
+
+	if len(ids) == 0 {
+		return 0, nil
+	}
+
+	return r.GalleryRepo.MarkBroken(ids)
+}
+
+func (r *Reconciler) move(disk storage.Storage, key, quarantineKey string) error {
+	if err := copyFile(disk, r.Quarantine, key, quarantineKey); err != nil {
+		return err
+	}
+
+	return disk.Delete(key)
+}
+
+func copyFile(src, dest storage.Storage, key, destKey string) error {
+	obj, err := src.Stat(key)
+	if err != nil {
+		return err
+	}
+
+	r, err := src.Get(key)
+	if err != nil {
+		return err
+	}
+	defer r.Close()
+
+	return dest.Put(destKey, r, obj.Size, obj.ContentType)
+}
+
+func diskName(isPrivate bool) string {
+	if isPrivate {
+		return "private"
+	}
+	return "public"
+}
@@ -0,0 +1,146 @@
+package repositories
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"nova-cdn/internal/models"
+	"strconv"
+	"time"
+)
+
+// GalleryCursorColumns are the sort columns usable with cursors; keyset
+// pagination needs columns that are never NULL. deleted_at is only allowed
+// when listing the trash alone.
+var GalleryCursorColumns = map[string]bool{
+	"id":         true,
+	"user_id":    true,
+	"group_code": true,
+	"file_size":  true,
+	"file_path":  true,
+	"created_at": true,
+	"deleted_at": true,
+}
+
+// GalleryCursor marks a position in a sorted gallery listing: the sort value
+// and id of the row it was taken from, and the direction to read from there.
+type GalleryCursor struct {
+	Sort     string `json:"s"`
+	Value    string `json:"v"`
+	ID       uint   `json:"i"`
+	Backward bool   `json:"b,omitempty"`
+}
+
+func NewGalleryCursor(gallery models.Gallery, sort string, backward bool) GalleryCursor {
+	column, _ := GalleryFilter{Sort: sort}.SortColumn()
+
+	var value string
+	switch column {
+	case "user_id":
+		value = strconv.FormatUint(uint64(gallery.UserID), 10)
+	case "group_code":
+		value = gallery.GroupCode
+	case "file_size":
+		value = strconv.FormatUint(uint64(gallery.FileSize), 10)
+	case "file_path":
+		value = gallery.FilePath
+	case "created_at":
+		value = gallery.CreatedAt.Format(time.RFC3339Nano)
+	case "deleted_at":
+		value = gallery.DeletedAt.Time.Format(time.RFC3339Nano)
+	}
+
+	return GalleryCursor{Sort: sort, Value: value, ID: gallery.ID, Backward: backward}
+}
+
+// Encode returns the cursor as an opaque URL-safe token.
+func (c GalleryCursor) Encode() string {
+	data, _ := json.Marshal(c)
+	return base64.RawURLEncoding.EncodeToString(data)
+}
+
+func DecodeGalleryCursor(token string) (*GalleryCursor, error) {
+	data, err := base64.RawURLEncoding.DecodeString(token)
+	if err != nil {
+		return nil, fmt.Errorf("invalid cursor")
+	}
+
+	var cursor GalleryCursor
+	if err := json.Unmarshal(data, &cursor); err != nil {
+		return nil, fmt.Errorf("invalid cursor")
+	}
+
+	return &cursor, nil
+}
+
+// value converts the stored sort value back to the column type.
+func (c GalleryCursor) value(column string) (interface{}, error) {
+	switch column {
+	case "id":
+		return c.ID, nil
+	case "user_id", "file_size":
+		return strconv.ParseUint(c.Value, 10, 64)
+	case "created_at", "deleted_at":
+		return time.Parse(time.RFC3339Nano, c.Value)
+	}
+	return c.Value, nil
+}
+
+// FindPage returns up to limit rows after (or, for a backward cursor,
+// before) the cursor in the filter's order, and whether more rows follow in
+// that direction. Rows are always returned in the filter's order.
+func (r *GalleryRepository) FindPage(filter GalleryFilter, cursor *GalleryCursor, limit int) ([]models.Gallery, bool, error) {
+	column, desc := filter.SortColumn()
+
+	backward := cursor != nil && cursor.Backward
+	query := filter.apply(r.db)
+
+	if cursor != nil {
+		value, err := cursor.value(column)
+		if err != nil {
+			return nil, false, fmt.Errorf("invalid cursor")
+		}
+
+		op := ">"
+		if desc != backward {
+			op = "<"
+		}
+
+		if column == "id" {
+			query = query.Where("id "+op+" ?", cursor.ID)
+		} else {
+			query = query.Where("("+column+" "+op+" ? OR ("+column+" = ? AND id "+op+" ?))", value, value, cursor.ID)
+		}
+	}
+
+	order := GalleryFilter{Sort: filter.Sort}
+	if backward {
+		order.Sort = reverseSort(filter.Sort)
+	}
+
+	var galleries []models.Gallery
+	if err := order.order(query).Limit(limit + 1).Find(&galleries).Error; err != nil {
+		return nil, false, err
+	}
+
+	hasMore := len(galleries) > limit
+	if hasMore {
+		galleries = galleries[:limit]
+	}
+
+	if backward {
+		for i, j := 0, len(galleries)-1; i < j; i, j = i+1, j-1 {
+			galleries[i], galleries[j] = galleries[j], galleries[i]
+		}
+	}
+
+	return galleries, hasMore, nil
+}
+
+func reverseSort(sort string) string {
+	column, desc := GalleryFilter{Sort: sort}.SortColumn()
+	if desc {
+		return column
+	}
+	return "-" + column
+}
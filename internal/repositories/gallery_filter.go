@@ -0,0 +1,144 @@
+package repositories
+
+import (
+	"strings"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// GallerySortColumns are the indexed columns galleries can be sorted by.
+var GallerySortColumns = map[string]bool{
+	"id":         true,
+	"user_id":    true,
+	"group_code": true,
+	"checksum":   true,
+	"file_size":  true,
+	"file_path":  true,
+	"created_at": true,
+	"deleted_at": true,
+}
+
+// TrashedFilters are the accepted values of the trashed filter: "" for live
+// rows only, "with" to include trashed rows and "only" for trashed rows alone.
+var TrashedFilters = map[string]bool{"": true, "with": true, "only": true}
+
+// GalleryFilter holds every filter of the gallery listings. Zero values and
+// nil pointers mean "no filter". It is the single place where listing
+// conditions are built, so the rows and their count always agree.
+type GalleryFilter struct {
+	SubjectID   string
+	SubjectType string
+	Size        string
+	MimeType    string
+	Search      string
+	Trashed     string
+	UserID      *uint
+	IsPrivate   *bool
+	CreatedFrom *time.Time
+	CreatedTo   *time.Time
+	MinWidth    *uint64
+	MaxWidth    *uint64
+	MinHeight   *uint64
+	MaxHeight   *uint64
+	MinFileSize *uint64
+	MaxFileSize *uint64
+	// Sort is a column of GallerySortColumns, prefixed with "-" for
+	// descending order. Ties are broken by id.
+	Sort string
+}
+
+func (f GalleryFilter) apply(query *gorm.DB) *gorm.DB {
+	query = withTrashed(query, f.Trashed)
+
+	if f.SubjectID != "" {
+		query = query.Where("subject_id = ?", f.SubjectID)
+	}
+
+	if f.SubjectType != "" {
+		query = query.Where("subject_type = ?", f.SubjectType)
+	}
+
+	if f.Size != "" {
+		query = query.Where("size = ?", f.Size)
+	}
+
+	if f.MimeType != "" {
+		query = query.Where("mime_type = ?", f.MimeType)
+	}
+
+	if f.Search != "" {
+		query = query.Where("description LIKE ? ESCAPE '!'", "%"+escapeLike(f.Search)+"%")
+	}
+
+	if f.UserID != nil {
+		query = query.Where("user_id = ?", *f.UserID)
+	}
+
+	if f.IsPrivate != nil {
+		query = query.Where("is_private = ?", *f.IsPrivate)
+	}
+
+	if f.CreatedFrom != nil {
+		query = query.Where("created_at >= ?", *f.CreatedFrom)
+	}
+
+	if f.CreatedTo != nil {
+		query = query.Where("created_at <= ?", *f.CreatedTo)
+	}
+
+	query = whereRange(query, "width", f.MinWidth, f.MaxWidth)
+	query = whereRange(query, "height", f.MinHeight, f.MaxHeight)
+	query = whereRange(query, "file_size", f.MinFileSize, f.MaxFileSize)
+
+	return query
+}
+
+// SortColumn returns the sort column and whether the order is descending,
+// defaulting to ascending id.
+func (f GalleryFilter) SortColumn() (string, bool) {
+	column := strings.TrimPrefix(f.Sort, "-")
+	if !GallerySortColumns[column] {
+		return "id", false
+	}
+	return column, strings.HasPrefix(f.Sort, "-")
+}
+
+func (f GalleryFilter) order(query *gorm.DB) *gorm.DB {
+	column, desc := f.SortColumn()
+
+	direction := "ASC"
+	if desc {
+		direction = "DESC"
+	}
+
+	query = query.Order(column + " " + direction)
+	if column != "id" {
+		query = query.Order("id " + direction)
+	}
+	return query
+}
+
+func withTrashed(query *gorm.DB, trashed string) *gorm.DB {
+	switch trashed {
+	case "with":
+		return query.Unscoped()
+	case "only":
+		return query.Unscoped().Where("deleted_at IS NOT NULL")
+	}
+	return query
+}
+
+func whereRange(query *gorm.DB, column string, min, max *uint64) *gorm.DB {
+	if min != nil {
+		query = query.Where(column+" >= ?", *min)
+	}
+	if max != nil {
+		query = query.Where(column+" <= ?", *max)
+	}
+	return query
+}
+
+func escapeLike(value string) string {
+	return strings.NewReplacer("!", "!!", "%", "!%", "_", "!_").Replace(value)
+}
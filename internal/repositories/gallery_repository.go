@@ -2,6 +2,7 @@ package repositories
 
 import (
 	"nova-cdn/internal/models"
+	"time"
 
 	"gorm.io/gorm"
 )
@@ -14,44 +15,25 @@ func NewGalleryRepository(db *gorm.DB) *GalleryRepository {
 	return &GalleryRepository{db: db}
 }
 
-func (r *GalleryRepository) FindAllPaginated(page, limit int, subject_id string, subject_type string, size string) ([]models.Gallery, error) {
+// ForUser returns a repository whose queries only see the galleries owned by
+// userID.
+func (r *GalleryRepository) ForUser(userID uint) *GalleryRepository {
+	return &GalleryRepository{db: r.db.Where("user_id = ?", userID).Session(&gorm.Session{})}
+}
+
+func (r *GalleryRepository) FindAllPaginated(filter GalleryFilter, page, limit int) ([]models.Gallery, error) {
 	var galleries []models.Gallery
 	offset := (page - 1) * limit
-	query := r.db.Offset(offset).Limit(limit)
-
-	if subject_id != "" {
-		query = query.Where("subject_id = ?", subject_id)
-	}
 
-	if subject_type != "" {
-		query = query.Where("subject_type = ?", subject_type)
-	}
-
-	if size != "" {
-		query = query.Where("size = ?", size)
-	}
+	query := filter.order(filter.apply(r.db)).Offset(offset).Limit(limit)
 
 	err := query.Find(&galleries).Error
 	return galleries, err
 }
 
-func (r *GalleryRepository) Count(subject_id string, subject_type string, size string) (int64, error) {
+func (r *GalleryRepository) Count(filter GalleryFilter) (int64, error) {
 	var count int64
-	query := r.db.Model(&models.Gallery{})
-
-	if subject_id != "" {
-		query = query.Where("subject_id = ?", subject_id)
-	}
-
-	if subject_type != "" {
-		query = query.Where("subject_type = ?", subject_type)
-	}
-
-	if size != "" {
-		query = query.Where("size = ?", size)
-	}
-
-	err := query.Count(&count).Error
+	err := filter.apply(r.db).Model(&models.Gallery{}).Count(&count).Error
 	return count, err
 }
 
@@ -74,6 +56,96 @@ func (r *GalleryRepository) CreateMany(galleries []*models.Gallery) error {
 	})
 }
 
+func (r *GalleryRepository) UpdateFields(gallery *models.Gallery, fields map[string]interface{}) error {
+	return r.db.Unscoped().Model(gallery).Updates(fields).Error
+}
+
+func (r *GalleryRepository) ForceDeleteVariants(groupCode string) error {
+	return r.db.Unscoped().Where("group_code = ? AND size <> ?", groupCode, "original").Delete(&models.Gallery{}).Error
+}
+
+// FindOriginalByChecksum looks for an original with the same content on the
+// same disk, trashed rows included since their files are still around.
+func (r *GalleryRepository) FindOriginalByChecksum(checksum string, isPrivate bool) (*models.Gallery, error) {
+	var gallery models.Gallery
+	err := r.db.Unscoped().
+		Where("checksum = ? AND is_private = ? AND size = ?", checksum, isPrivate, "original").
+		Order("id ASC").
+		First(&gallery).Error
+	return &gallery, err
+}
+
+// FindChecksumByFilePath returns the stored checksum of a public file of the
+// given size, or "" when no row knows it. Trashed rows count, their files are
+// still served until they are purged.
+func (r *GalleryRepository) FindChecksumByFilePath(filePath string, size int64) (string, error) {
+	var checksums []string
+	err := r.db.Unscoped().Model(&models.Gallery{}).
+		Where("file_path = ? AND is_private = ? AND file_size = ? AND checksum <> ?", filePath, false, size, "").
+		Limit(1).
+		Pluck("checksum", &checksums).Error
+	if err != nil || len(checksums) == 0 {
+		return "", err
+	}
+	return checksums[0], nil
+}
+
+func (r *GalleryRepository) FindVariantsByGroupCode(groupCode string) ([]models.Gallery, error) {
+	var galleries []models.Gallery
+	err := r.db.Unscoped().Where("group_code = ? AND size <> ?", groupCode, "original").Find(&galleries).Error
+	return galleries, err
+}
+
+// CountByFilePath returns how many rows, trashed ones included, still point to
+// a physical file. Deduplicated uploads share their files between groups.
+func (r *GalleryRepository) CountByFilePath(filePath string, isPrivate bool) (int64, error) {
+	var count int64
+	err := r.db.Unscoped().Model(&models.Gallery{}).
+		Where("file_path = ? AND is_private = ?", filePath, isPrivate).
+		Count(&count).Error
+	return count, err
+}
+
+// ForceDeleteTrashedBefore permanently deletes up to limit rows trashed before
+// cutoff and returns them so their files can be removed.
+func (r *GalleryRepository) ForceDeleteTrashedBefore(cutoff time.Time, limit int) ([]models.Gallery, error) {
+	var galleries []models.Gallery
+
+	err := r.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Unscoped().Where("deleted_at IS NOT NULL AND deleted_at < ?", cutoff).Order("id").Limit(limit).Find(&galleries).Error; err != nil {
+			return err
+		}
+
+		if len(galleries) == 0 {
+			return nil
+		}
+
+		ids := make([]uint, 0, len(galleries))
+		for _, gallery := range galleries {
+			ids = append(ids, gallery.ID)
+		}
+
+		return tx.Unscoped().Where("id IN ?", ids).Delete(&models.Gallery{}).Error
+	})
+
+	return galleries, err
+}
+
+// FindAllFiles loads the file columns of every row, trashed ones included.
+func (r *GalleryRepository) FindAllFiles() ([]models.Gallery, error) {
+	var galleries []models.Gallery
+	err := r.db.Unscoped().
+		Select("id", "file_path", "is_private", "group_code", "size", "deleted_at").
+		Order("id").
+		Find(&galleries).Error
+	return galleries, err
+}
+
+func (r *GalleryRepository) MarkBroken(ids []uint) (int64, error) {
+	result := r.db.Unscoped().Model(&models.Gallery{}).Where("id IN ?", ids).Update("broken_at", time.Now())
+	return result.RowsAffected, result.Error
+}
+
 func (r *GalleryRepository) FindByID(id uint64, withDeleted bool) (*models.Gallery, error) {
 	var gallery models.Gallery
 	if withDeleted {
@@ -99,19 +171,24 @@ func (r *GalleryRepository) Restore(gallery *models.Gallery) error {
 	return err
 }
 
-func (r *GalleryRepository) RestoreByGroupCode(groupCode string, size string) error {
+func (r *GalleryRepository) RestoreByGroupCode(groupCode string, size string) (int64, error) {
 	query := r.db.Unscoped().Model(&models.Gallery{}).Where("group_code = ?", groupCode)
 
 	if size != "" {
 		query = query.Where("size = ?", size)
 	}
 
-	return query.Update("deleted_at", nil).Error
+	result := query.Update("deleted_at", nil)
+	return result.RowsAffected, result.Error
 }
 
 func (r *GalleryRepository) FindByGroupCode(groupCode string, size string) ([]models.Gallery, error) {
+	return r.FindByGroupCodeWithTrashed(groupCode, size, "")
+}
+
+func (r *GalleryRepository) FindByGroupCodeWithTrashed(groupCode string, size string, trashed string) ([]models.Gallery, error) {
 	var galleries []models.Gallery
-	query := r.db.Where("group_code = ?", groupCode)
+	query := withTrashed(r.db, trashed).Where("group_code = ?", groupCode)
 
 	if size != "" {
 		query = query.Where("size = ?", size)
@@ -122,14 +199,15 @@ func (r *GalleryRepository) FindByGroupCode(groupCode string, size string) ([]mo
 	return galleries, err
 }
 
-func (r *GalleryRepository) DeleteByGroupCode(groupCode string, size string) error {
+func (r *GalleryRepository) DeleteByGroupCode(groupCode string, size string) (int64, error) {
 	query := r.db.Where("group_code = ?", groupCode)
 
 	if size != "" {
 		query = query.Where("size = ?", size)
 	}
 
-	return query.Delete(&models.Gallery{}).Error
+	result := query.Delete(&models.Gallery{})
+	return result.RowsAffected, result.Error
 }
 
 func (r *GalleryRepository) ForceDeleteByGroupCode(groupCode string, size string) ([]models.Gallery, error) {
@@ -4,6 +4,7 @@ import (
 	"nova-cdn/internal/models"
 
 	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
 )
 
 type GenerateRepository struct {
@@ -26,3 +27,27 @@ func (r *GenerateRepository) FindByAlias(alias string) (*models.Generate, error)
 func (r *GenerateRepository) Update(generate *models.Generate) error {
 	return r.db.Save(generate).Error
 }
+
+// FindByAliasForUpdate locks the sequence row until the surrounding
+// transaction ends. SQLite has no row locks but serializes writers anyway.
+func (r *GenerateRepository) FindByAliasForUpdate(alias string) (*models.Generate, error) {
+	var generate models.Generate
+	err := r.db.Unscoped().Clauses(clause.Locking{Strength: "UPDATE"}).Where("alias = ?", alias).First(&generate).Error
+	if err != nil {
+		return nil, err
+	}
+	return &generate, nil
+}
+
+func (r *GenerateRepository) UpdateSequence(generate *models.Generate) error {
+	return r.db.Unscoped().Model(generate).Updates(map[string]interface{}{
+		"queue":     generate.Queue,
+		"separator": generate.Separator,
+	}).Error
+}
+
+func (r *GenerateRepository) Transaction(fn func(repo *GenerateRepository) error) error {
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		return fn(NewGenerateRepository(tx))
+	})
+}
@@ -0,0 +1,106 @@
+package repositories
+
+import (
+	"nova-cdn/internal/models"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+type ImageJobRepository struct {
+	db *gorm.DB
+}
+
+func NewImageJobRepository(db *gorm.DB) *ImageJobRepository {
+	return &ImageJobRepository{db: db}
+}
+
+func (r *ImageJobRepository) Create(job *models.ImageJob) error {
+	return r.db.Create(job).Error
+}
+
+func (r *ImageJobRepository) FindByGroupCode(groupCode string) (*models.ImageJob, error) {
+	var job models.ImageJob
+	err := r.db.Where("group_code = ?", groupCode).Order("id DESC").First(&job).Error
+	return &job, err
+}
+
+// ClaimNext marks the oldest runnable job as processing and returns it. The
+// conditional UPDATE makes sure only one worker can claim a given job, it
+// returns gorm.ErrRecordNotFound when there is nothing to do.
+func (r *ImageJobRepository) ClaimNext() (*models.ImageJob, error) {
+	var candidates []models.ImageJob
+
+	err := r.db.Where("status = ? AND available_at <= ?", models.JobStatusPending, time.Now()).
+		Order("available_at ASC, id ASC").
+		Limit(10).
+		Find(&candidates).Error
+	if err != nil {
+		return nil, err
+	}
+
+	for _, job := range candidates {
+		now := time.Now()
+
+		result := r.db.Model(&models.ImageJob{}).
+			Where("id = ? AND status = ?", job.ID, models.JobStatusPending).
+			Updates(map[string]interface{}{
+				"status":     models.JobStatusProcessing,
+				"attempts":   gorm.Expr("attempts + 1"),
+				"started_at": now,
+				"updated_at": now,
+			})
+		if result.Error != nil {
+			return nil, result.Error
+		}
+
+		if result.RowsAffected == 1 {
+			job.Status = models.JobStatusProcessing
+			job.Attempts++
+			job.StartedAt = &now
+			return &job, nil
+		}
+	}
+
+	return nil, gorm.ErrRecordNotFound
+}
+
+func (r *ImageJobRepository) MarkDone(job *models.ImageJob) error {
+	now := time.Now()
+	return r.db.Model(job).Updates(map[string]interface{}{
+		"status":      models.JobStatusDone,
+		"last_error":  nil,
+		"finished_at": now,
+	}).Error
+}
+
+// MarkFailed puts the job back in the queue after the given backoff, or marks
+// it as failed for good once it ran out of attempts.
+func (r *ImageJobRepository) MarkFailed(job *models.ImageJob, cause error, backoff time.Duration) error {
+	message := cause.Error()
+	fields := map[string]interface{}{
+		"last_error": message,
+	}
+
+	if job.Attempts >= job.MaxAttempts {
+		fields["status"] = models.JobStatusFailed
+		fields["finished_at"] = time.Now()
+	} else {
+		fields["status"] = models.JobStatusPending
+		fields["available_at"] = time.Now().Add(backoff)
+	}
+
+	return r.db.Model(job).Updates(fields).Error
+}
+
+// ReleaseStale puts jobs that have been processing for longer than timeout,
+// e.g. because the process was killed, back in the queue.
+func (r *ImageJobRepository) ReleaseStale(timeout time.Duration) (int64, error) {
+	result := r.db.Model(&models.ImageJob{}).
+		Where("status = ? AND started_at < ?", models.JobStatusProcessing, time.Now().Add(-timeout)).
+		Updates(map[string]interface{}{
+			"status":       models.JobStatusPending,
+			"available_at": time.Now(),
+		})
+	return result.RowsAffected, result.Error
+}
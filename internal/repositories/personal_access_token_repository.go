@@ -40,3 +40,20 @@ func (repo PersonalAccessTokenRepository) DeleteByUserID(userID uint) error {
 func (repo PersonalAccessTokenRepository) UpdateFields(token *models.PersonalAccessToken, fields map[string]interface{}) error {
 	return repo.db.Model(token).Updates(fields).Error
 }
+
+func (repo PersonalAccessTokenRepository) FindByUserID(userID uint) ([]models.PersonalAccessToken, error) {
+	var tokens []models.PersonalAccessToken
+	err := repo.db.Where("tokenable_type = ? AND tokenable_id = ?", "App\\Models\\User", userID).Order("id DESC").Find(&tokens).Error
+	return tokens, err
+}
+
+func (repo PersonalAccessTokenRepository) FindByIDAndUserID(id uint64, userID uint) (*models.PersonalAccessToken, error) {
+	var token models.PersonalAccessToken
+
+	result := repo.db.Where("id = ? AND tokenable_type = ? AND tokenable_id = ?", id, "App\\Models\\User", userID).First(&token)
+	if result.Error != nil {
+		return nil, result.Error
+	}
+
+	return &token, nil
+}
@@ -19,3 +19,9 @@ func (r *UserRepository) FindByEmail(email string) (*models.User, error) {
 	result := r.db.Where("email = ?", email).First(&user)
 	return &user, result.Error
 }
+
+func (r *UserRepository) FindByID(id uint) (*models.User, error) {
+	var user models.User
+	result := r.db.First(&user, id)
+	return &user, result.Error
+}
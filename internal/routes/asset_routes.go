@@ -0,0 +1,16 @@
+package routes
+
+import (
+	"nova-cdn/internal/controllers"
+
+	"github.com/gofiber/fiber/v2"
+	"gorm.io/gorm"
+)
+
+// AssetRoutes serves the public disk. It is registered last so that it only
+// sees paths no other route claimed.
+func AssetRoutes(app *fiber.App, db *gorm.DB) {
+	assetController := controllers.NewAssetController(db)
+
+	app.Get("/*", assetController.Show)
+}
@@ -3,6 +3,7 @@ package routes
 import (
 	"nova-cdn/internal/controllers"
 	"nova-cdn/internal/middleware"
+	"nova-cdn/internal/models"
 
 	"github.com/gofiber/fiber/v2"
 	"gorm.io/gorm"
@@ -10,9 +11,19 @@ import (
 
 func AuthRoutes(api fiber.Router, db *gorm.DB) {
 	authController := controllers.NewAuthController(db)
+	tokenController := controllers.NewTokenController(db)
 
 	auth := api.Group("/auth")
 
-	auth.Use(middleware.AuthLimiter())
-	auth.Post("/login", authController.Login)
+	auth.Post("/login", middleware.AuthLimiter(), authController.Login)
+	auth.Post("/logout", middleware.Auth(db), authController.Logout)
+
+	canManage := middleware.Ability(models.AbilityTokenManage)
+
+	tokens := auth.Group("/tokens", middleware.Auth(db))
+
+	tokens.Get("/", canManage, tokenController.Index)
+	tokens.Post("/", canManage, tokenController.Store)
+	tokens.Delete("/", canManage, tokenController.DestroyAll)
+	tokens.Delete("/:id<int>", canManage, tokenController.Destroy)
 }
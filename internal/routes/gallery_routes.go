@@ -3,6 +3,7 @@ package routes
 import (
 	"nova-cdn/internal/controllers"
 	"nova-cdn/internal/middleware"
+	"nova-cdn/internal/models"
 
 	"github.com/gofiber/fiber/v2"
 	"gorm.io/gorm"
@@ -10,20 +11,38 @@ import (
 
 func GalleryRoutes(api fiber.Router, db *gorm.DB) {
 	galleryController := controllers.NewGalleryController(db)
+	tusController := controllers.NewTusController(db)
 
 	galleries := api.Group("/galleries", middleware.Auth(db))
 
-	galleries.Get("/", galleryController.Index)
-	galleries.Post("/upload", galleryController.Upload)
-	galleries.Get("/:id<int>", galleryController.Show)
-	galleries.Get("/:group_code<string>", galleryController.ShowByGroupCode)
+	canRead := middleware.Ability(models.AbilityGalleryRead)
+	canWrite := middleware.Ability(models.AbilityGalleryWrite)
+	canDelete := middleware.Ability(models.AbilityGalleryDelete)
+	canForceDelete := middleware.Ability(models.AbilityGalleryForceDelete)
 
-	galleries.Post("/:id<int>/restore", galleryController.Restore)
-	galleries.Post("/:group_code<string>/restore", galleryController.RestoreByGroupCode)
+	galleries.Get("/", canRead, galleryController.Index)
+	galleries.Get("/trash", canRead, galleryController.Trash)
+	galleries.Post("/upload", canWrite, galleryController.Upload)
+	galleries.Post("/files", canWrite, galleryController.UploadFile)
 
-	galleries.Delete("/:id<int>", galleryController.Destroy)
-	galleries.Delete("/:group_code<string>", galleryController.DestroyByGroupCode)
+	galleries.Options("/tus", canWrite, tusController.Options)
+	galleries.Post("/tus", canWrite, tusController.Create)
+	galleries.Head("/tus/:id", canWrite, tusController.Head)
+	galleries.Patch("/tus/:id", canWrite, tusController.Patch)
+	galleries.Delete("/tus/:id", canWrite, tusController.Delete)
+	galleries.Get("/:id<int>", canRead, galleryController.Show)
+	galleries.Get("/:group_code<string>", canRead, galleryController.ShowByGroupCode)
+	galleries.Get("/:group_code<string>/status", canRead, galleryController.Status)
 
-	galleries.Delete("/:id<int>/force", galleryController.ForceDelete)
-	galleries.Delete("/:group_code<string>/force", galleryController.ForceDeleteByGroupCode)
+	galleries.Get("/:id<int>/signed-url", canRead, galleryController.SignedURL)
+	galleries.Get("/:group_code<string>/signed-url", canRead, galleryController.SignedURLByGroupCode)
+
+	galleries.Post("/:id<int>/restore", canDelete, galleryController.Restore)
+	galleries.Post("/:group_code<string>/restore", canDelete, galleryController.RestoreByGroupCode)
+
+	galleries.Delete("/:id<int>", canDelete, galleryController.Destroy)
+	galleries.Delete("/:group_code<string>", canDelete, galleryController.DestroyByGroupCode)
+
+	galleries.Delete("/:id<int>/force", canForceDelete, galleryController.ForceDelete)
+	galleries.Delete("/:group_code<string>/force", canForceDelete, galleryController.ForceDeleteByGroupCode)
 }
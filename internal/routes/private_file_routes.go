@@ -0,0 +1,14 @@
+package routes
+
+import (
+	"nova-cdn/internal/controllers"
+
+	"github.com/gofiber/fiber/v2"
+	"gorm.io/gorm"
+)
+
+func PrivateFileRoutes(app *fiber.App, db *gorm.DB) {
+	privateFileController := controllers.NewPrivateFileController(db)
+
+	app.Get("/private/:group_code", privateFileController.Show)
+}
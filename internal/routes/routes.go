@@ -3,6 +3,7 @@ package routes
 import (
 	"nova-cdn/internal/config"
 	"nova-cdn/internal/middleware"
+	"nova-cdn/internal/storage"
 
 	"github.com/gofiber/fiber/v2"
 	"github.com/gofiber/swagger"
@@ -12,7 +13,13 @@ func SetupRoutes(app *fiber.App) {
 	db := config.GetDB()
 
 	app.Use(middleware.GlobalLimiter())
-	app.Static("/", "./public")
+
+	if config.FilesystemDisk == "local" || config.FilesystemDisk == "public" {
+		app.Use("/images", middleware.ImageNegotiation(storage.GetStorage().Public))
+	}
+
+	PrivateFileRoutes(app, db)
+	TransformRoutes(app, db)
 
 	api := app.Group("/api")
 
@@ -27,4 +34,8 @@ func SetupRoutes(app *fiber.App) {
 
 	AuthRoutes(api, db)
 	GalleryRoutes(api, db)
+
+	if config.FilesystemDisk == "local" || config.FilesystemDisk == "public" {
+		AssetRoutes(app, db)
+	}
 }
@@ -0,0 +1,14 @@
+package routes
+
+import (
+	"nova-cdn/internal/controllers"
+
+	"github.com/gofiber/fiber/v2"
+	"gorm.io/gorm"
+)
+
+func TransformRoutes(app *fiber.App, db *gorm.DB) {
+	transformController := controllers.NewTransformController(db)
+
+	app.Get("/transform/:group_code", transformController.Show)
+}
@@ -13,15 +13,20 @@
 package service
 
 import (
+	"bytes"
 	"fmt"
-	"mime/multipart"
+	"image/png"
+	"io"
+	"nova-cdn/internal/config"
 	"nova-cdn/internal/dto"
 	"nova-cdn/internal/models"
+	"nova-cdn/internal/preview"
 	"nova-cdn/internal/repositories"
+	"nova-cdn/internal/storage"
 	"nova-cdn/pkg/utils"
-	"os"
-	"path/filepath"
+	"path"
 	"strconv"
+	"time"
 
 	"github.com/gofiber/fiber/v2"
 	"github.com/google/uuid"
@@ -30,45 +35,121 @@ import (
 
 type GalleryService interface {
 	Upload(c *fiber.Ctx) error
+	UploadFile(c *fiber.Ctx) error
+	Store(input *dto.UploadInput, file *dto.UploadFile) ([]models.Gallery, error)
+	StoreFile(input *dto.UploadInput, file *dto.UploadFile) ([]models.Gallery, error)
+	ParseUploadInput(userID uint, value func(key string, defaultValue ...string) string) (*dto.UploadInput, error)
+	ProcessVariants(job *models.ImageJob) error
+	RemoveFiles(galleries []models.Gallery)
 }
 
+// ImageProcessor writes the optimized versions of src to store.
+type ImageProcessor func(store storage.Storage, src io.Reader, outputDir, baseName string) ([]utils.ProcessedImage, error)
+
 type galleryService struct {
+	DB           *gorm.DB
 	GalleryRepo  *repositories.GalleryRepository
 	GenerateRepo *repositories.GenerateRepository
+	Storage      *storage.Disks
+	Processor    ImageProcessor
+	Previews     preview.Renderers
+	NewStaging   func() (*storage.Staging, error)
 }
 
 func NewGalleryService(db *gorm.DB) GalleryService {
 	return &galleryService{
+		DB:           db,
 		GalleryRepo:  repositories.NewGalleryRepository(db),
 		GenerateRepo: repositories.NewGenerateRepository(db),
+		Storage:      storage.GetStorage(),
+		Processor:    utils.ProcessImage,
+		Previews:     preview.Default(),
+		NewStaging:   storage.NewStaging,
 	}
 }
 
 func (s *galleryService) Upload(c *fiber.Ctx) error {
-	file, err := c.FormFile("file")
+	fileHeader, err := c.FormFile("file")
 
 	if err != nil {
 		return utils.ErrorResponse(c, fiber.StatusBadRequest, "No file uploaded")
 	}
 
-	if err := s.validateFile(file); err != nil {
-		return utils.ErrorResponse(c, fiber.StatusBadRequest, err.Error())
+	if fileHeader.Size > dto.MaxUploadSize {
+		return utils.ErrorResponse(c, fiber.StatusBadRequest, "file size exceeds 10MB limit")
 	}
 
-	input, err := s.parseUploadInput(c)
+	input, err := s.ParseUploadInput(c.Locals("user_id").(uint), c.FormValue)
 	if err != nil {
 		return utils.ErrorResponse(c, fiber.StatusBadRequest, err.Error())
 	}
 
-	filePath, newFileName, err := s.saveFileToDisk(c, file, input.Dir)
+	src, err := fileHeader.Open()
+	if err != nil {
+		return utils.ErrorResponse(c, fiber.StatusInternalServerError, "Failed to read uploaded file")
+	}
+	defer src.Close()
+
+	galleries, err := s.Store(input, &dto.UploadFile{
+		FileName:    fileHeader.Filename,
+		ContentType: fileHeader.Header.Get("Content-Type"),
+		Size:        fileHeader.Size,
+		Reader:      src,
+	})
+
+	if e, ok := err.(*fiber.Error); ok {
+		return utils.ErrorResponse(c, e.Code, e.Message)
+	}
+
 	if err != nil {
 		return utils.ErrorResponse(c, fiber.StatusInternalServerError, err.Error())
 	}
 
-	fullPath := filepath.Join(dto.UploadDirBase, filePath)
-	outputDir := filepath.Join(dto.UploadDirBase, "images", input.Dir)
+	return utils.AcceptedResponse(c, "Image uploaded successfully, optimized versions are being processed", galleries)
+}
+
+// Store validates and persists an uploaded original and queues the generation
+// of its optimized versions. It is shared by the multipart and resumable upload
+// endpoints; validation problems are returned as *fiber.Error.
+func (s *galleryService) Store(input *dto.UploadInput, file *dto.UploadFile) ([]models.Gallery, error) {
+	detected, err := s.validateFile(file)
+	if err != nil {
+		return nil, fiber.NewError(fiber.StatusBadRequest, err.Error())
+	}
+
+	checksum, err := utils.Checksum(file.Reader)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read uploaded file")
+	}
+
+	disk := s.Storage.For(input.IsPrivate)
+
+	var existing *models.Gallery
+	if config.DeduplicateUploads {
+		existing = s.findReusableOriginal(disk, checksum, input.IsPrivate)
+	}
+
+	var filePath, newFileName string
+	var staging *storage.Staging
+
+	if existing != nil {
+		filePath, newFileName = existing.FilePath, existing.FileName
+	} else {
+		if staging, err = s.NewStaging(); err != nil {
+			return nil, err
+		}
+		defer staging.Cleanup()
+
+		filePath, newFileName, err = s.saveFile(staging, file, detected.Ext, path.Join(dto.ImageRoot, input.Dir))
+		if err != nil {
+			return nil, err
+		}
+	}
 
-	groupCode := utils.GetCode(s.GenerateRepo, "gallery_group", true)
+	groupCode, err := utils.GetCode(s.GenerateRepo, "gallery_group", true)
+	if err != nil {
+		return nil, fmt.Errorf("failed to generate group code")
+	}
 
 	original := models.Gallery{
 		UserID:       input.UserID,
@@ -77,61 +158,446 @@ func (s *galleryService) Upload(c *fiber.Ctx) error {
 		FileName:     newFileName,
 		FilePath:     filePath,
 		FileSize:     uint32(file.Size),
+		Checksum:     checksum,
+		Width:        uint(detected.Width),
+		Height:       uint(detected.Height),
 		Description:  input.Description,
 		IsPrivate:    input.IsPrivate,
 		Size:         "original",
-		HasOptimized: true,
+		Format:       utils.FormatFromMimeType(file.ContentType),
+		MimeType:     file.ContentType,
+		HasOptimized: existing != nil && existing.HasOptimized,
 		GroupCode:    groupCode,
 	}
 
-	if err := s.GalleryRepo.Create(&original); err != nil {
-		return utils.ErrorResponse(c, fiber.StatusInternalServerError, "Failed to save original image metadata")
+	if err := s.createOriginal(disk, staging, &original, existing, true); err != nil {
+		return nil, fmt.Errorf("failed to save original image metadata")
+	}
+
+	galleries, err := s.GalleryRepo.FindByGroupCode(groupCode, "")
+	if err != nil {
+		return nil, fmt.Errorf("failed to get image metadata")
+	}
+
+	return galleries, nil
+}
+
+// UploadFile stores any allowed file type. Images take the regular image
+// pipeline, other files are stored as they are.
+func (s *galleryService) UploadFile(c *fiber.Ctx) error {
+	fileHeader, err := c.FormFile("file")
+
+	if err != nil {
+		return utils.ErrorResponse(c, fiber.StatusBadRequest, "No file uploaded")
+	}
+
+	input, err := s.ParseUploadInput(c.Locals("user_id").(uint), c.FormValue)
+	if err != nil {
+		return utils.ErrorResponse(c, fiber.StatusBadRequest, err.Error())
+	}
+
+	src, err := fileHeader.Open()
+	if err != nil {
+		return utils.ErrorResponse(c, fiber.StatusInternalServerError, "Failed to read uploaded file")
+	}
+	defer src.Close()
+
+	galleries, err := s.StoreFile(input, &dto.UploadFile{
+		FileName:    fileHeader.Filename,
+		ContentType: fileHeader.Header.Get("Content-Type"),
+		Size:        fileHeader.Size,
+		Reader:      src,
+	})
+
+	if e, ok := err.(*fiber.Error); ok {
+		return utils.ErrorResponse(c, e.Code, e.Message)
+	}
+
+	if err != nil {
+		return utils.ErrorResponse(c, fiber.StatusInternalServerError, err.Error())
+	}
+
+	if dto.AllowedMimeTypes[galleries[0].MimeType] {
+		return utils.AcceptedResponse(c, "Image uploaded successfully, optimized versions are being processed", galleries)
+	}
+
+	if _, ok := s.Previews.For(galleries[0].MimeType); ok && !galleries[0].HasOptimized {
+		return utils.AcceptedResponse(c, "File uploaded successfully, previews are being processed", galleries)
+	}
+
+	return utils.CreatedResponse(c, "File uploaded successfully", galleries)
+}
+
+// StoreFile checks the sniffed type of an upload against the configured
+// allowlist and its size limit, then hands images to Store and saves any other
+// file under files/. Files with a preview renderer, such as PDFs and videos
+// when ffmpeg is installed, get their variants generated from the preview; the
+// others are stored as they are.
+func (s *galleryService) StoreFile(input *dto.UploadInput, file *dto.UploadFile) ([]models.Gallery, error) {
+	detected, err := utils.DetectFileType(file.Reader, file.FileName)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read uploaded file")
+	}
+
+	limit, ok := config.UploadAllowedTypes[detected.MimeType]
+	if !ok {
+		return nil, fiber.NewError(fiber.StatusUnsupportedMediaType, fmt.Sprintf("file type %s is not allowed", detected.MimeType))
+	}
+
+	if file.Size > limit {
+		return nil, fiber.NewError(fiber.StatusRequestEntityTooLarge, fmt.Sprintf("file size exceeds the %dMB limit for %s", limit/1024/1024, detected.MimeType))
+	}
+
+	if dto.AllowedMimeTypes[detected.MimeType] {
+		return s.Store(input, file)
 	}
 
-	processedImages, err := utils.ProcessImage(fullPath, outputDir, newFileName)
+	file.ContentType = detected.MimeType
+
+	checksum, err := utils.Checksum(file.Reader)
 	if err != nil {
-		return utils.ErrorResponse(c, fiber.StatusInternalServerError, "Failed to process image: "+err.Error())
+		return nil, fmt.Errorf("failed to read uploaded file")
+	}
+
+	disk := s.Storage.For(input.IsPrivate)
+
+	var existing *models.Gallery
+	if config.DeduplicateUploads {
+		existing = s.findReusableOriginal(disk, checksum, input.IsPrivate)
 	}
 
-	if len(processedImages) > 0 {
-		processedGalleries := s.buildProcessedGalleries(input, processedImages, filePath, groupCode)
+	var filePath, newFileName string
+	var staging *storage.Staging
 
-		if err := s.GalleryRepo.CreateMany(processedGalleries); err != nil {
-			return utils.ErrorResponse(c, fiber.StatusInternalServerError, "Failed to save processed images metadata")
+	if existing != nil {
+		filePath, newFileName = existing.FilePath, existing.FileName
+	} else {
+		if staging, err = s.NewStaging(); err != nil {
+			return nil, err
 		}
+		defer staging.Cleanup()
+
+		filePath, newFileName, err = s.saveFile(staging, file, detected.Ext, path.Join(dto.FileRoot, input.Dir))
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	groupCode, err := utils.GetCode(s.GenerateRepo, "gallery_group", true)
+	if err != nil {
+		return nil, fmt.Errorf("failed to generate group code")
+	}
+
+	original := models.Gallery{
+		UserID:      input.UserID,
+		SubjectID:   input.SubjectID,
+		SubjectType: input.SubjectType,
+		FileName:    newFileName,
+		FilePath:    filePath,
+		FileSize:    uint32(file.Size),
+		Checksum:    checksum,
+		Description: input.Description,
+		IsPrivate:   input.IsPrivate,
+		Size:        "original",
+		Format:      detected.Format,
+		MimeType:    detected.MimeType,
+		GroupCode:   groupCode,
+	}
+
+	_, hasPreview := s.Previews.For(detected.MimeType)
+	original.HasOptimized = hasPreview && existing != nil && existing.HasOptimized
+
+	// A duplicate shares the metadata read along with its previews.
+	if original.HasOptimized {
+		original.Width, original.Height = existing.Width, existing.Height
+		original.Duration, original.Codec, original.Bitrate = existing.Duration, existing.Codec, existing.Bitrate
+	}
+
+	if err := s.createOriginal(disk, staging, &original, existing, hasPreview); err != nil {
+		return nil, fmt.Errorf("failed to save file metadata")
 	}
 
 	galleries, err := s.GalleryRepo.FindByGroupCode(groupCode, "")
 	if err != nil {
-		return utils.ErrorResponse(c, fiber.StatusInternalServerError, "Failed to get processed images metadata")
+		return nil, fmt.Errorf("failed to get file metadata")
 	}
 
-	return utils.CreatedResponse(c, "Image uploaded successfully", galleries)
+	return galleries, nil
 }
 
-func (s *galleryService) validateFile(file *multipart.FileHeader) error {
-	contentType := file.Header.Get("Content-Type")
-	if !dto.AllowedMimeTypes[contentType] {
-		return fmt.Errorf("invalid file type. Only JPEG, PNG, GIF, and WebP are allowed")
+// createOriginal inserts the original row, with the variants shared by its
+// duplicate or, when queue is set, the job generating them. The staged upload
+// is promoted to disk last, inside the transaction, so a failure at any step
+// leaves neither rows nor files behind. staging is nil for duplicates, which
+// reuse the file already on disk.
+func (s *galleryService) createOriginal(disk storage.Storage, staging *storage.Staging, original, existing *models.Gallery, queue bool) error {
+	var promoted []string
+
+	err := s.DB.Transaction(func(tx *gorm.DB) error {
+		galleryRepo := repositories.NewGalleryRepository(tx)
+
+		if err := galleryRepo.Create(original); err != nil {
+			return err
+		}
+
+		// The optimized versions of a duplicate already exist, share them too.
+		if original.HasOptimized {
+			variants, err := galleryRepo.FindVariantsByGroupCode(existing.GroupCode)
+			if err != nil {
+				return err
+			}
+
+			if len(variants) > 0 {
+				if err := galleryRepo.CreateMany(s.cloneVariants(original, variants)); err != nil {
+					return err
+				}
+			}
+		} else if queue {
+			err := repositories.NewImageJobRepository(tx).Create(&models.ImageJob{
+				GalleryID:   original.ID,
+				GroupCode:   original.GroupCode,
+				Status:      models.JobStatusPending,
+				MaxAttempts: config.JobMaxAttempts,
+				AvailableAt: time.Now(),
+			})
+			if err != nil {
+				return err
+			}
+		}
+
+		if staging == nil {
+			return nil
+		}
+
+		var err error
+		promoted, err = staging.Promote(disk)
+		return err
+	})
+
+	if err != nil {
+		for _, key := range promoted {
+			disk.Delete(key)
+		}
+	}
+
+	return err
+}
+
+// ProcessVariants generates the optimized versions of the original referenced
+// by the job, rendering a preview first for files that aren't images. It is
+// called by the image worker pool, and is safe to retry: any variant rows left
+// by a previous attempt are replaced.
+func (s *galleryService) ProcessVariants(job *models.ImageJob) error {
+	original, err := s.GalleryRepo.FindByID(uint64(job.GalleryID), true)
+	if err != nil {
+		return fmt.Errorf("original image not found: %w", err)
+	}
+
+	disk := s.Storage.For(original.IsPrivate)
+
+	src, err := disk.Get(original.FilePath)
+	if err != nil {
+		return fmt.Errorf("failed to read original image: %w", err)
+	}
+	defer src.Close()
+
+	staging, err := s.NewStaging()
+	if err != nil {
+		return err
+	}
+	defer staging.Cleanup()
+
+	source, info, err := s.previewSource(original, src)
+	if err != nil {
+		return err
+	}
+
+	processedImages, err := s.Processor(staging, source, path.Dir(original.FilePath), original.FileName)
+	if err != nil {
+		return err
 	}
-	if file.Size > dto.MaxUploadSize {
-		return fmt.Errorf("file size exceeds 10MB limit")
+
+	promoted, err := staging.Promote(disk)
+	if err != nil {
+		s.removeUnreferenced(original.IsPrivate, promoted)
+		return err
+	}
+
+	processedGalleries := s.buildProcessedGalleries(original, processedImages)
+
+	err = s.DB.Transaction(func(tx *gorm.DB) error {
+		galleryRepo := repositories.NewGalleryRepository(tx)
+
+		if err := galleryRepo.ForceDeleteVariants(original.GroupCode); err != nil {
+			return err
+		}
+
+		if len(processedGalleries) > 0 {
+			if err := galleryRepo.CreateMany(processedGalleries); err != nil {
+				return err
+			}
+		}
+
+		fields := map[string]interface{}{"has_optimized": true}
+		if info != nil {
+			fields["width"] = uint(info.Width)
+			fields["height"] = uint(info.Height)
+			fields["duration"] = info.Duration.Seconds()
+			fields["codec"] = info.Codec
+			fields["bitrate"] = info.Bitrate
+		}
+
+		return galleryRepo.UpdateFields(original, fields)
+	})
+
+	if err != nil {
+		s.removeUnreferenced(original.IsPrivate, promoted)
+		return err
 	}
+
 	return nil
 }
 
-func (s *galleryService) parseUploadInput(c *fiber.Ctx) (*dto.UploadInput, error) {
-	userID := c.Locals("user_id").(uint)
+// previewSource returns the image the variants of original are made from: the
+// file itself for images, its rendered preview for the other types. Renderers
+// that read metadata, such as the duration and codec of videos, return it too.
+func (s *galleryService) previewSource(original *models.Gallery, src io.ReadSeeker) (io.Reader, *preview.MediaInfo, error) {
+	renderer, ok := s.Previews.For(original.MimeType)
+	if !ok {
+		return src, nil, nil
+	}
+
+	var info *preview.MediaInfo
+	if describer, ok := renderer.(preview.Describer); ok {
+		var err error
+		if info, err = describer.Describe(src); err != nil {
+			return nil, nil, fmt.Errorf("failed to read media metadata: %w", err)
+		}
+	}
+
+	img, err := renderer.Render(src)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to render preview: %w", err)
+	}
+
+	var buf bytes.Buffer
+	encoder := png.Encoder{CompressionLevel: png.BestSpeed}
+	if err := encoder.Encode(&buf, img); err != nil {
+		return nil, nil, fmt.Errorf("failed to encode preview: %w", err)
+	}
+
+	return &buf, info, nil
+}
+
+// RemoveFiles deletes the physical files of force-deleted rows, skipping the
+// ones still referenced by other rows through deduplication.
+func (s *galleryService) RemoveFiles(galleries []models.Gallery) {
+	for _, isPrivate := range []bool{false, true} {
+		var paths []string
+		for _, gallery := range galleries {
+			if gallery.IsPrivate == isPrivate {
+				paths = append(paths, gallery.FilePath)
+			}
+		}
+		s.removeUnreferenced(isPrivate, paths)
+	}
+}
+
+// removeUnreferenced deletes the given files of a disk unless a row still
+// points to them. It is also the compensating step of a failed variant run:
+// files overwritten in place for rows of an earlier attempt are kept.
+func (s *galleryService) removeUnreferenced(isPrivate bool, paths []string) {
+	disk := s.Storage.For(isPrivate)
+	seen := map[string]bool{}
+
+	for _, filePath := range paths {
+		if seen[filePath] {
+			continue
+		}
+		seen[filePath] = true
+
+		count, err := s.GalleryRepo.CountByFilePath(filePath, isPrivate)
+		if err != nil || count > 0 {
+			continue
+		}
+
+		utils.RemoveImageFiles(disk, filePath)
+	}
+}
+
+func (s *galleryService) findReusableOriginal(disk storage.Storage, checksum string, isPrivate bool) *models.Gallery {
+	existing, err := s.GalleryRepo.FindOriginalByChecksum(checksum, isPrivate)
+	if err != nil {
+		return nil
+	}
+
+	if _, err := disk.Stat(existing.FilePath); err != nil {
+		return nil
+	}
+
+	return existing
+}
+
+func (s *galleryService) cloneVariants(original *models.Gallery, variants []models.Gallery) []*models.Gallery {
+	var result []*models.Gallery
+
+	for _, variant := range variants {
+		result = append(result, &models.Gallery{
+			UserID:       original.UserID,
+			SubjectID:    original.SubjectID,
+			SubjectType:  original.SubjectType,
+			FileName:     variant.FileName,
+			FilePath:     variant.FilePath,
+			FileSize:     variant.FileSize,
+			Checksum:     variant.Checksum,
+			Width:        variant.Width,
+			Height:       variant.Height,
+			Description:  original.Description,
+			IsPrivate:    original.IsPrivate,
+			HasOptimized: false,
+			Size:         variant.Size,
+			Format:       variant.Format,
+			MimeType:     variant.MimeType,
+			GroupCode:    original.GroupCode,
+		})
+	}
+	return result
+}
+
+// validateFile sniffs and fully decodes the upload. The client supplied
+// Content-Type is replaced by the detected one so it can't smuggle other
+// content under an image name.
+func (s *galleryService) validateFile(file *dto.UploadFile) (*utils.DetectedImage, error) {
+	detected, err := utils.ValidateImage(file.Reader, utils.ImageLimits{
+		MaxWidth:  config.ImageMaxWidth,
+		MaxHeight: config.ImageMaxHeight,
+		MaxPixels: config.ImageMaxPixels,
+	})
+
+	if err == utils.ErrUnsupportedImage || (err == nil && !dto.AllowedMimeTypes[detected.MimeType]) {
+		return nil, fmt.Errorf("invalid file type. Only JPEG, PNG, GIF, and WebP are allowed")
+	}
+
+	if err != nil {
+		return nil, err
+	}
+
+	file.ContentType = detected.MimeType
+	return detected, nil
+}
 
+// ParseUploadInput builds the upload options from form values, or from any
+// other source exposing the same lookup signature as fiber.Ctx.FormValue.
+func (s *galleryService) ParseUploadInput(userID uint, value func(key string, defaultValue ...string) string) (*dto.UploadInput, error) {
 	input := &dto.UploadInput{
-		Dir:         c.FormValue("dir", dto.DefaultImageDir),
-		Description: c.FormValue("description", ""),
-		IsPrivate:   c.FormValue("is_private", "false") == "true",
+		Dir:         value("dir", dto.DefaultImageDir),
+		Description: value("description", ""),
+		IsPrivate:   value("is_private", "false") == "true",
 		UserID:      userID,
 	}
 
-	subjectIDStr := c.FormValue("subject_id", "")
-	subjectTypeStr := c.FormValue("subject_type", "")
+	subjectIDStr := value("subject_id", "")
+	subjectTypeStr := value("subject_type", "")
 
 	if subjectIDStr != "" {
 		sid, err := strconv.ParseUint(subjectIDStr, 10, 32)
@@ -152,8 +618,7 @@ func (s *galleryService) parseUploadInput(c *fiber.Ctx) (*dto.UploadInput, error
 	return input, nil
 }
 
-func (s *galleryService) saveFileToDisk(c *fiber.Ctx, file *multipart.FileHeader, dir string) (string, string, error) {
-	ext := filepath.Ext(file.Filename)
+func (s *galleryService) saveFile(disk storage.Storage, file *dto.UploadFile, ext string, dir string) (string, string, error) {
 	newUid, err := uuid.NewV7()
 
 	if err != nil {
@@ -161,38 +626,40 @@ func (s *galleryService) saveFileToDisk(c *fiber.Ctx, file *multipart.FileHeader
 	}
 
 	newFileName := fmt.Sprintf("%v%s", newUid.String(), ext)
-	relativePath := fmt.Sprintf("images/%s/%s", dir, newFileName)
-	fullPath := filepath.Join(dto.UploadDirBase, relativePath)
-	outputDir := filepath.Join(dto.UploadDirBase, "images", dir)
+	relativePath := fmt.Sprintf("%s/%s", dir, newFileName)
 
-	if err := os.MkdirAll(outputDir, 0755); err != nil {
-		return "", "", fmt.Errorf("failed to create directory: %w", err)
+	if _, err := file.Reader.Seek(0, io.SeekStart); err != nil {
+		return "", "", fmt.Errorf("failed to read uploaded file: %w", err)
 	}
 
-	if err := c.SaveFile(file, fullPath); err != nil {
+	if err := disk.Put(relativePath, file.Reader, file.Size, file.ContentType); err != nil {
 		return "", "", fmt.Errorf("failed to save file: %w", err)
 	}
 
 	return relativePath, newFileName, nil
 }
 
-func (s *galleryService) buildProcessedGalleries(input *dto.UploadInput, processedImages []utils.ProcessedImage, originalPath string, groupCode string) []*models.Gallery {
+func (s *galleryService) buildProcessedGalleries(original *models.Gallery, processedImages []utils.ProcessedImage) []*models.Gallery {
 	var result []*models.Gallery
-	dirPath := filepath.Dir(originalPath)
 
 	for _, img := range processedImages {
 		result = append(result, &models.Gallery{
-			UserID:       input.UserID,
-			SubjectID:    input.SubjectID,
-			SubjectType:  input.SubjectType,
+			UserID:       original.UserID,
+			SubjectID:    original.SubjectID,
+			SubjectType:  original.SubjectType,
 			FileName:     img.FileName,
-			FilePath:     fmt.Sprintf("%s/%s", dirPath, img.FileName),
+			FilePath:     img.FilePath,
 			FileSize:     img.FileSize,
-			Description:  input.Description,
-			IsPrivate:    input.IsPrivate,
+			Checksum:     img.Checksum,
+			Width:        uint(img.Width),
+			Height:       uint(img.Height),
+			Description:  original.Description,
+			IsPrivate:    original.IsPrivate,
 			HasOptimized: false,
 			Size:         img.Size,
-			GroupCode:    groupCode,
+			Format:       img.Format,
+			MimeType:     utils.ImageFormats[img.Format].MimeType,
+			GroupCode:    original.GroupCode,
 		})
 	}
 	return result
@@ -0,0 +1,480 @@
+package service
+
+import (
+	"bytes"
+	"errors"
+	"image"
+	"image/color"
+	"image/png"
+	"io"
+	"io/fs"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"nova-cdn/internal/config"
+	"nova-cdn/internal/dto"
+	"nova-cdn/internal/migrations"
+	"nova-cdn/internal/models"
+	"nova-cdn/internal/preview"
+	"nova-cdn/internal/repositories"
+	"nova-cdn/internal/storage"
+	"nova-cdn/pkg/utils"
+
+	"github.com/glebarez/sqlite"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+)
+
+var errInjected = errors.New("injected failure")
+
+// newTestService returns a gallery service backed by a migrated in-memory
+// SQLite database and local disks under a temporary directory.
+func newTestService(t *testing.T) *galleryService {
+	t.Helper()
+
+	db, err := gorm.Open(sqlite.Open(":memory:"), &gorm.Config{Logger: logger.Default.LogMode(logger.Silent)})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	sqlDB, err := db.DB()
+	if err != nil {
+		t.Fatal(err)
+	}
+	sqlDB.SetMaxOpenConns(1)
+	t.Cleanup(func() { sqlDB.Close() })
+
+	migrator, err := migrations.New(db)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := migrator.Up(); err != nil {
+		t.Fatal(err)
+	}
+
+	root := t.TempDir()
+	config.FilesystemDisk = "local"
+	config.FilesystemRoot = filepath.Join(root, "public")
+	config.FilesystemPrivateRoot = filepath.Join(root, "private")
+	config.StagingDir = filepath.Join(root, "staging")
+	config.DeduplicateUploads = false
+	config.JobMaxAttempts = 5
+	storage.ConnectStorage()
+
+	return &galleryService{
+		DB:           db,
+		GalleryRepo:  repositories.NewGalleryRepository(db),
+		GenerateRepo: repositories.NewGenerateRepository(db),
+		Storage:      storage.GetStorage(),
+		Processor:    fakeProcessor,
+		Previews:     preview.Renderers{},
+		NewStaging:   storage.NewStaging,
+	}
+}
+
+// fakeProcessor writes two small variants without encoding anything.
+func fakeProcessor(store storage.Storage, src io.Reader, outputDir, baseName string) ([]utils.ProcessedImage, error) {
+	if _, err := io.Copy(io.Discard, src); err != nil {
+		return nil, err
+	}
+
+	var results []utils.ProcessedImage
+	for _, size := range []string{"small", "medium"} {
+		fileName := size + "-" + strings.TrimSuffix(baseName, filepath.Ext(baseName)) + ".jpg"
+		filePath := path.Join(outputDir, fileName)
+
+		if err := store.Put(filePath, strings.NewReader(size), int64(len(size)), "image/jpeg"); err != nil {
+			return nil, err
+		}
+
+		results = append(results, utils.ProcessedImage{
+			FileName: fileName,
+			FilePath: filePath,
+			FileSize: uint32(len(size)),
+			Size:     size,
+			Format:   "jpeg",
+			Width:    4,
+			Height:   4,
+		})
+	}
+	return results, nil
+}
+
+// failingDisk fails every Put of a key matched by fail.
+type failingDisk struct {
+	storage.Storage
+	fail func(key string) bool
+}
+
+func (d *failingDisk) Put(key string, r io.Reader, size int64, contentType string) error {
+	if d.fail(key) {
+		return errInjected
+	}
+	return d.Storage.Put(key, r, size, contentType)
+}
+
+// failOn makes every statement of the given kind ("create", "update" or
+// "query") on table fail.
+func failOn(t *testing.T, db *gorm.DB, kind, table string) {
+	t.Helper()
+
+	fail := func(tx *gorm.DB) {
+		if tx.Statement.Table == table {
+			tx.AddError(errInjected)
+		}
+	}
+
+	var err error
+	switch kind {
+	case "create":
+		err = db.Callback().Create().Before("gorm:create").Register("test:fail", fail)
+	case "update":
+		err = db.Callback().Update().Before("gorm:update").Register("test:fail", fail)
+	case "query":
+		err = db.Callback().Query().Before("gorm:query").Register("test:fail", fail)
+	}
+	if err != nil {
+		t.Fatal(err)
+	}
+}
+
+func testUpload(t *testing.T) (*dto.UploadInput, *dto.UploadFile) {
+	t.Helper()
+
+	img := image.NewRGBA(image.Rect(0, 0, 8, 8))
+	for x := 0; x < 8; x++ {
+		img.Set(x, x, color.RGBA{R: 255, A: 255})
+	}
+
+	var buf bytes.Buffer
+	if err := png.Encode(&buf, img); err != nil {
+		t.Fatal(err)
+	}
+
+	input := &dto.UploadInput{Dir: "gallery", UserID: 1}
+	file := &dto.UploadFile{
+		FileName:    "test.png",
+		ContentType: "image/png",
+		Size:        int64(buf.Len()),
+		Reader:      bytes.NewReader(buf.Bytes()),
+	}
+	return input, file
+}
+
+func listFiles(t *testing.T, root string) []string {
+	t.Helper()
+
+	var files []string
+	err := filepath.WalkDir(root, func(p string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if !d.IsDir() {
+			rel, _ := filepath.Rel(root, p)
+			files = append(files, filepath.ToSlash(rel))
+		}
+		return nil
+	})
+	if err != nil && !errors.Is(err, fs.ErrNotExist) {
+		t.Fatal(err)
+	}
+	return files
+}
+
+func countRows(t *testing.T, db *gorm.DB, model interface{}) int64 {
+	t.Helper()
+
+	var count int64
+	if err := db.Unscoped().Model(model).Count(&count).Error; err != nil {
+		t.Fatal(err)
+	}
+	return count
+}
+
+func assertStagingEmpty(t *testing.T) {
+	t.Helper()
+
+	if files := listFiles(t, config.StagingDir); len(files) > 0 {
+		t.Errorf("staging still holds %v", files)
+	}
+}
+
+func TestStoreFailuresLeaveNothingBehind(t *testing.T) {
+	tests := []struct {
+		name   string
+		inject func(t *testing.T, s *galleryService)
+	}{
+		{
+			name: "save file",
+			inject: func(t *testing.T, s *galleryService) {
+				// A regular file as the staging root makes every write fail.
+				root := filepath.Join(t.TempDir(), "stage")
+				if err := os.WriteFile(root, nil, 0644); err != nil {
+					t.Fatal(err)
+				}
+				s.NewStaging = func() (*storage.Staging, error) {
+					return &storage.Staging{LocalStorage: storage.NewLocalStorage(root, "")}, nil
+				}
+			},
+		},
+		{
+			name: "group code",
+			inject: func(t *testing.T, s *galleryService) {
+				failOn(t, s.DB, "update", "generates")
+			},
+		},
+		{
+			name: "original row",
+			inject: func(t *testing.T, s *galleryService) {
+				failOn(t, s.DB, "create", "galleries")
+			},
+		},
+		{
+			name: "job row",
+			inject: func(t *testing.T, s *galleryService) {
+				failOn(t, s.DB, "create", "image_jobs")
+			},
+		},
+		{
+			name: "promote",
+			inject: func(t *testing.T, s *galleryService) {
+				s.Storage = &storage.Disks{
+					Public:  &failingDisk{Storage: s.Storage.Public, fail: func(string) bool { return true }},
+					Private: s.Storage.Private,
+				}
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := newTestService(t)
+			tt.inject(t, s)
+
+			input, file := testUpload(t)
+			if _, err := s.Store(input, file); err == nil {
+				t.Fatal("Store succeeded, want an error")
+			}
+
+			if count := countRows(t, s.DB, &models.Gallery{}); count != 0 {
+				t.Errorf("%d gallery rows left behind", count)
+			}
+			if count := countRows(t, s.DB, &models.ImageJob{}); count != 0 {
+				t.Errorf("%d job rows left behind", count)
+			}
+			if files := listFiles(t, config.FilesystemRoot); len(files) > 0 {
+				t.Errorf("files left behind: %v", files)
+			}
+			assertStagingEmpty(t)
+		})
+	}
+}
+
+func TestProcessVariantsFailuresLeaveNothingBehind(t *testing.T) {
+	tests := []struct {
+		name   string
+		inject func(t *testing.T, s *galleryService)
+	}{
+		{
+			name: "processor",
+			inject: func(t *testing.T, s *galleryService) {
+				s.Processor = func(store storage.Storage, src io.Reader, outputDir, baseName string) ([]utils.ProcessedImage, error) {
+					if err := store.Put(path.Join(outputDir, "small-"+baseName), strings.NewReader("small"), 5, "image/png"); err != nil {
+						return nil, err
+					}
+					return nil, errInjected
+				}
+			},
+		},
+		{
+			name: "partial promote",
+			inject: func(t *testing.T, s *galleryService) {
+				s.Storage = &storage.Disks{
+					Public: &failingDisk{Storage: s.Storage.Public, fail: func(key string) bool {
+						return strings.Contains(key, "medium-")
+					}},
+					Private: s.Storage.Private,
+				}
+			},
+		},
+		{
+			name: "variant rows",
+			inject: func(t *testing.T, s *galleryService) {
+				failOn(t, s.DB, "create", "galleries")
+			},
+		},
+		{
+			name: "original update",
+			inject: func(t *testing.T, s *galleryService) {
+				failOn(t, s.DB, "update", "galleries")
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := newTestService(t)
+
+			input, file := testUpload(t)
+			galleries, err := s.Store(input, file)
+			if err != nil {
+				t.Fatal(err)
+			}
+			original := galleries[0]
+
+			job, err := repositories.NewImageJobRepository(s.DB).FindByGroupCode(original.GroupCode)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			tt.inject(t, s)
+
+			if err := s.ProcessVariants(job); err == nil {
+				t.Fatal("ProcessVariants succeeded, want an error")
+			}
+
+			if count := countRows(t, s.DB, &models.Gallery{}); count != 1 {
+				t.Errorf("got %d gallery rows, want only the original", count)
+			}
+
+			files := listFiles(t, config.FilesystemRoot)
+			if len(files) != 1 || files[0] != original.FilePath {
+				t.Errorf("got files %v, want only %s", files, original.FilePath)
+			}
+
+			reloaded, err := s.GalleryRepo.FindByID(uint64(original.ID), true)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if reloaded.HasOptimized {
+				t.Error("original is marked as optimized")
+			}
+			assertStagingEmpty(t)
+		})
+	}
+}
+
+func TestStoreAndProcessVariants(t *testing.T) {
+	s := newTestService(t)
+
+	input, file := testUpload(t)
+	galleries, err := s.Store(input, file)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(galleries) != 1 || galleries[0].Size != "original" {
+		t.Fatalf("got %+v, want the original only", galleries)
+	}
+	original := galleries[0]
+
+	if files := listFiles(t, config.FilesystemRoot); len(files) != 1 || files[0] != original.FilePath {
+		t.Fatalf("got files %v, want %s", files, original.FilePath)
+	}
+
+	job, err := repositories.NewImageJobRepository(s.DB).FindByGroupCode(original.GroupCode)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if err := s.ProcessVariants(job); err != nil {
+		t.Fatal(err)
+	}
+
+	galleries, err = s.GalleryRepo.FindByGroupCode(original.GroupCode, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(galleries) != 3 {
+		t.Fatalf("got %d rows, want the original and 2 variants", len(galleries))
+	}
+
+	for _, gallery := range galleries {
+		if _, err := s.Storage.Public.Stat(gallery.FilePath); err != nil {
+			t.Errorf("%s: %v", gallery.FilePath, err)
+		}
+		if gallery.Size == "original" && !gallery.HasOptimized {
+			t.Error("original is not marked as optimized")
+		}
+	}
+	assertStagingEmpty(t)
+}
+
+// fakeProber describes every file as the same video and returns a blank frame
+// for the first second only.
+type fakeProber struct{}
+
+func (fakeProber) Probe(r io.ReadSeeker) (*preview.MediaInfo, error) {
+	return &preview.MediaInfo{Duration: 1500 * time.Millisecond, Width: 640, Height: 360, Codec: "h264", Bitrate: 800000}, nil
+}
+
+func (fakeProber) Frame(r io.ReadSeeker, at time.Duration) (image.Image, error) {
+	if at > time.Second {
+		return nil, errors.New("offset is past the end of the video")
+	}
+	return image.NewRGBA(image.Rect(0, 0, 8, 8)), nil
+}
+
+func TestProcessVariantsStoresVideoMetadata(t *testing.T) {
+	s := newTestService(t)
+	s.Previews = preview.Renderers{"video/*": &preview.VideoRenderer{Prober: fakeProber{}, PosterOffset: 3 * time.Second}}
+	allowed := config.UploadAllowedTypes
+	config.UploadAllowedTypes = map[string]int64{"video/mp4": 1 << 20}
+	t.Cleanup(func() { config.UploadAllowedTypes = allowed })
+
+	// Just enough of an MP4 for the content to be sniffed as one.
+	data := append([]byte("\x00\x00\x00\x18ftypisom\x00\x00\x02\x00isommp41"), make([]byte, 64)...)
+	input := &dto.UploadInput{Dir: "gallery", UserID: 1}
+	file := &dto.UploadFile{FileName: "clip.mp4", ContentType: "video/mp4", Size: int64(len(data)), Reader: bytes.NewReader(data)}
+
+	galleries, err := s.StoreFile(input, file)
+	if err != nil {
+		t.Fatal(err)
+	}
+	original := galleries[0]
+	if original.MimeType != "video/mp4" {
+		t.Fatalf("got mime type %q, want video/mp4", original.MimeType)
+	}
+
+	job, err := repositories.NewImageJobRepository(s.DB).FindByGroupCode(original.GroupCode)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := s.ProcessVariants(job); err != nil {
+		t.Fatal(err)
+	}
+
+	reloaded, err := s.GalleryRepo.FindByID(uint64(original.ID), true)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if reloaded.Duration == nil || *reloaded.Duration != 1.5 {
+		t.Errorf("got duration %v, want 1.5", reloaded.Duration)
+	}
+	if reloaded.Codec == nil || *reloaded.Codec != "h264" {
+		t.Errorf("got codec %v, want h264", reloaded.Codec)
+	}
+	if reloaded.Bitrate == nil || *reloaded.Bitrate != 800000 {
+		t.Errorf("got bitrate %v, want 800000", reloaded.Bitrate)
+	}
+	if reloaded.Width != 640 || reloaded.Height != 360 {
+		t.Errorf("got %dx%d, want 640x360", reloaded.Width, reloaded.Height)
+	}
+	if !reloaded.HasOptimized {
+		t.Error("original is not marked as optimized")
+	}
+
+	// The poster comes from the first frame since the offset is past the end.
+	variants, err := s.GalleryRepo.FindVariantsByGroupCode(original.GroupCode)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(variants) != 2 {
+		t.Fatalf("got %d variants, want 2", len(variants))
+	}
+}
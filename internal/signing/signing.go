@@ -0,0 +1,70 @@
+package signing
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"net/url"
+	"nova-cdn/internal/config"
+	"strconv"
+	"time"
+)
+
+// Sign returns the HMAC-SHA256 signature for a private file identified by its
+// group code, size and format variant, valid until the given unix timestamp.
+// An empty format lets the server negotiate it from the Accept header.
+func Sign(groupCode, size, format string, expires int64) string {
+	return hash(fmt.Sprintf("%s\n%s\n%s\n%d", groupCode, size, format, expires))
+}
+
+func Verify(groupCode, size, format string, expires int64, signature string) bool {
+	expected := Sign(groupCode, size, format, expires)
+	return hmac.Equal([]byte(expected), []byte(signature))
+}
+
+func URL(groupCode, size, format string, ttl time.Duration) (string, time.Time) {
+	expiresAt := time.Now().Add(ttl)
+	expires := expiresAt.Unix()
+
+	query := url.Values{}
+	if size != "" {
+		query.Set("size", size)
+	}
+	if format != "" {
+		query.Set("format", format)
+	}
+	query.Set("expires", strconv.FormatInt(expires, 10))
+	query.Set("signature", Sign(groupCode, size, format, expires))
+
+	return fmt.Sprintf("%s/private/%s?%s", config.AppURL, url.PathEscape(groupCode), query.Encode()), expiresAt
+}
+
+// SignTransform signs the canonical query of a transformation request so that
+// parameters outside the configured whitelist can still be served. An expires
+// value of zero produces a signature that never expires.
+func SignTransform(groupCode, params string, expires int64) string {
+	return hash(fmt.Sprintf("transform\n%s\n%s\n%d", groupCode, params, expires))
+}
+
+func VerifyTransform(groupCode, params string, expires int64, signature string) bool {
+	expected := SignTransform(groupCode, params, expires)
+	return hmac.Equal([]byte(expected), []byte(signature))
+}
+
+func TransformURL(groupCode, params string, ttl time.Duration) (string, time.Time) {
+	expiresAt := time.Now().Add(ttl)
+	expires := expiresAt.Unix()
+
+	query := url.Values{}
+	query.Set("expires", strconv.FormatInt(expires, 10))
+	query.Set("signature", SignTransform(groupCode, params, expires))
+
+	return fmt.Sprintf("%s/transform/%s?%s&%s", config.AppURL, url.PathEscape(groupCode), params, query.Encode()), expiresAt
+}
+
+func hash(message string) string {
+	mac := hmac.New(sha256.New, []byte(config.SignedURLKey))
+	mac.Write([]byte(message))
+	return hex.EncodeToString(mac.Sum(nil))
+}
@@ -0,0 +1,147 @@
+package storage
+
+import (
+	"errors"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"testing"
+)
+
+func put(t *testing.T, s Storage, key, content string) {
+	t.Helper()
+
+	if err := s.Put(key, strings.NewReader(content), int64(len(content)), "text/plain"); err != nil {
+		t.Fatalf("Put(%q): %v", key, err)
+	}
+}
+
+func read(t *testing.T, s Storage, key string) string {
+	t.Helper()
+
+	file, err := s.Get(key)
+	if err != nil {
+		t.Fatalf("Get(%q): %v", key, err)
+	}
+	defer file.Close()
+
+	data, err := io.ReadAll(file)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return string(data)
+}
+
+func TestLocalStorage(t *testing.T) {
+	parent := t.TempDir()
+	root := filepath.Join(parent, "disk")
+	s := NewLocalStorage(root, "https://cdn.example.com/")
+
+	tests := []struct {
+		name    string
+		key     string
+		content string
+		// path is where the file must land, relative to the disk root.
+		path string
+	}{
+		{"nested key", "images/gallery/a.jpg", "a", "images/gallery/a.jpg"},
+		{"leading slash", "/images/gallery/b.jpg", "b", "images/gallery/b.jpg"},
+		{"parent segments", "../../escape.txt", "escape", "escape.txt"},
+		{"inner parent segments", "images/../../../c.txt", "c", "c.txt"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			put(t, s, tt.key, tt.content)
+
+			data, err := os.ReadFile(filepath.Join(root, filepath.FromSlash(tt.path)))
+			if err != nil {
+				t.Fatalf("file is not at %s: %v", tt.path, err)
+			}
+			if string(data) != tt.content {
+				t.Fatalf("got %q on disk, want %q", data, tt.content)
+			}
+
+			if got := read(t, s, tt.key); got != tt.content {
+				t.Errorf("Get: got %q, want %q", got, tt.content)
+			}
+
+			info, err := s.Stat(tt.key)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if info.Key != tt.path || info.Size != int64(len(tt.content)) {
+				t.Errorf("Stat: got key %q and size %d, want %q and %d", info.Key, info.Size, tt.path, len(tt.content))
+			}
+		})
+	}
+
+	// Nothing was written next to the disk root.
+	if entries, err := os.ReadDir(parent); err != nil || len(entries) != 1 {
+		t.Fatalf("got %v entries next to the disk root: %v", entries, err)
+	}
+
+	t.Run("list", func(t *testing.T) {
+		objects, err := s.List("images")
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		var keys []string
+		for _, object := range objects {
+			keys = append(keys, object.Key)
+		}
+		sort.Strings(keys)
+
+		if want := []string{"images/gallery/a.jpg", "images/gallery/b.jpg"}; strings.Join(keys, ",") != strings.Join(want, ",") {
+			t.Errorf("got %v, want %v", keys, want)
+		}
+
+		if objects, err := s.List("../.."); err != nil || len(objects) != 4 {
+			t.Errorf("listing above the root got %d objects, want the 4 of the disk: %v", len(objects), err)
+		}
+		if objects, err := s.List("missing"); err != nil || len(objects) != 0 {
+			t.Errorf("got %v, %v for a missing prefix", objects, err)
+		}
+	})
+
+	t.Run("url", func(t *testing.T) {
+		if got := s.URL("/images/gallery/a.jpg"); got != "https://cdn.example.com/images/gallery/a.jpg" {
+			t.Errorf("got %q", got)
+		}
+	})
+
+	t.Run("missing key", func(t *testing.T) {
+		if _, err := s.Get("images/missing.jpg"); !errors.Is(err, ErrNotFound) {
+			t.Errorf("Get: got %v, want ErrNotFound", err)
+		}
+		if _, err := s.Stat("images/missing.jpg"); !errors.Is(err, ErrNotFound) {
+			t.Errorf("Stat: got %v, want ErrNotFound", err)
+		}
+		if _, err := s.Stat("images/gallery"); !errors.Is(err, ErrNotFound) {
+			t.Errorf("Stat of a directory: got %v, want ErrNotFound", err)
+		}
+		if err := s.Delete("images/missing.jpg"); !errors.Is(err, ErrNotFound) {
+			t.Errorf("Delete: got %v, want ErrNotFound", err)
+		}
+	})
+
+	t.Run("root key", func(t *testing.T) {
+		for _, key := range []string{"", "/", ".."} {
+			if err := s.Put(key, strings.NewReader("x"), 1, "text/plain"); err == nil {
+				t.Errorf("Put(%q) succeeded", key)
+			}
+		}
+	})
+
+	t.Run("delete", func(t *testing.T) {
+		if err := s.Delete("images/gallery/a.jpg"); err != nil {
+			t.Fatal(err)
+		}
+		if _, err := s.Stat("images/gallery/a.jpg"); !errors.Is(err, ErrNotFound) {
+			t.Errorf("got %v after deleting, want ErrNotFound", err)
+		}
+	})
+}
@@ -0,0 +1,173 @@
+package storage
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/minio/minio-go/v7"
+	"github.com/minio/minio-go/v7/pkg/credentials"
+)
+
+type S3Config struct {
+	Endpoint        string
+	Region          string
+	Bucket          string
+	AccessKeyID     string
+	SecretAccessKey string
+	UseSSL          bool
+	URL             string
+	Prefix          string
+}
+
+// S3Storage talks to any S3 compatible service (AWS, MinIO, R2, ...) using
+// path-style requests against the configured endpoint.
+type S3Storage struct {
+	client  *minio.Client
+	bucket  string
+	prefix  string
+	baseURL string
+}
+
+func NewS3Storage(cfg S3Config) (*S3Storage, error) {
+	if cfg.Bucket == "" {
+		return nil, fmt.Errorf("AWS_BUCKET is required for the s3 disk")
+	}
+
+	endpoint := cfg.Endpoint
+	secure := cfg.UseSSL
+
+	if strings.HasPrefix(endpoint, "http://") {
+		secure = false
+	} else if strings.HasPrefix(endpoint, "https://") {
+		secure = true
+	}
+
+	endpoint = strings.TrimPrefix(endpoint, "http://")
+	endpoint = strings.TrimPrefix(endpoint, "https://")
+	endpoint = strings.TrimSuffix(endpoint, "/")
+
+	if endpoint == "" {
+		endpoint = "s3.amazonaws.com"
+		if cfg.Region != "" {
+			endpoint = fmt.Sprintf("s3.%s.amazonaws.com", cfg.Region)
+		}
+	}
+
+	client, err := minio.New(endpoint, &minio.Options{
+		Creds:        credentials.NewStaticV4(cfg.AccessKeyID, cfg.SecretAccessKey, ""),
+		Secure:       secure,
+		Region:       cfg.Region,
+		BucketLookup: minio.BucketLookupPath,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to create s3 client: %w", err)
+	}
+
+	baseURL := strings.TrimSuffix(cfg.URL, "/")
+	if baseURL == "" {
+		scheme := "https"
+		if !secure {
+			scheme = "http"
+		}
+		baseURL = fmt.Sprintf("%s://%s/%s", scheme, endpoint, cfg.Bucket)
+	}
+
+	return &S3Storage{
+		client:  client,
+		bucket:  cfg.Bucket,
+		prefix:  cfg.Prefix,
+		baseURL: baseURL,
+	}, nil
+}
+
+func (s *S3Storage) Put(key string, r io.Reader, size int64, contentType string) error {
+	_, err := s.client.PutObject(context.Background(), s.bucket, s.key(key), r, size, minio.PutObjectOptions{
+		ContentType: contentType,
+	})
+	if err != nil {
+		return fmt.Errorf("failed to upload file: %w", err)
+	}
+	return nil
+}
+
+func (s *S3Storage) Get(key string) (io.ReadSeekCloser, error) {
+	object, err := s.client.GetObject(context.Background(), s.bucket, s.key(key), minio.GetObjectOptions{})
+	if err != nil {
+		return nil, s.wrapError(err)
+	}
+
+	// GetObject is lazy, so stat it to surface a missing key right away.
+	if _, err := object.Stat(); err != nil {
+		object.Close()
+		return nil, s.wrapError(err)
+	}
+
+	return object, nil
+}
+
+func (s *S3Storage) Delete(key string) error {
+	if _, err := s.Stat(key); err != nil {
+		return err
+	}
+	return s.wrapError(s.client.RemoveObject(context.Background(), s.bucket, s.key(key), minio.RemoveObjectOptions{}))
+}
+
+func (s *S3Storage) Stat(key string) (*Object, error) {
+	info, err := s.client.StatObject(context.Background(), s.bucket, s.key(key), minio.StatObjectOptions{})
+	if err != nil {
+		return nil, s.wrapError(err)
+	}
+
+	return &Object{
+		Key:         strings.TrimPrefix(info.Key, s.prefix),
+		Size:        info.Size,
+		ContentType: info.ContentType,
+		ModTime:     info.LastModified,
+	}, nil
+}
+
+func (s *S3Storage) List(prefix string) ([]Object, error) {
+	var objects []Object
+
+	opts := minio.ListObjectsOptions{
+		Prefix:    s.key(prefix),
+		Recursive: true,
+	}
+
+	for info := range s.client.ListObjects(context.Background(), s.bucket, opts) {
+		if info.Err != nil {
+			return nil, s.wrapError(info.Err)
+		}
+
+		objects = append(objects, Object{
+			Key:         strings.TrimPrefix(info.Key, s.prefix),
+			Size:        info.Size,
+			ContentType: info.ContentType,
+			ModTime:     info.LastModified,
+		})
+	}
+
+	return objects, nil
+}
+
+func (s *S3Storage) URL(key string) string {
+	return s.baseURL + "/" + s.key(key)
+}
+
+func (s *S3Storage) key(key string) string {
+	return s.prefix + strings.TrimPrefix(key, "/")
+}
+
+func (s *S3Storage) wrapError(err error) error {
+	if err == nil {
+		return nil
+	}
+
+	resp := minio.ToErrorResponse(err)
+	if resp.Code == "NoSuchKey" || resp.StatusCode == 404 {
+		return ErrNotFound
+	}
+	return err
+}
@@ -0,0 +1,114 @@
+package storage
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/minio/minio-go/v7"
+)
+
+// TestS3Storage runs against a real S3 compatible service and is skipped
+// unless S3_TEST_ENDPOINT is set, e.g. for a local MinIO:
+//
+//	docker run -p 9000:9000 minio/minio server /data
+//	S3_TEST_ENDPOINT=http://localhost:9000 go test ./internal/storage
+//
+// S3_TEST_ACCESS_KEY and S3_TEST_SECRET_KEY default to the MinIO ones and
+// S3_TEST_BUCKET, created when missing, to nova-cdn-test.
+func TestS3Storage(t *testing.T) {
+	endpoint := os.Getenv("S3_TEST_ENDPOINT")
+	if endpoint == "" {
+		t.Skip("S3_TEST_ENDPOINT is not set")
+	}
+
+	env := func(key, fallback string) string {
+		if value := os.Getenv(key); value != "" {
+			return value
+		}
+		return fallback
+	}
+
+	// Every run writes under a prefix of its own, so a shared bucket works.
+	prefix := fmt.Sprintf("test-%d/", time.Now().UnixNano())
+	s, err := NewS3Storage(S3Config{
+		Endpoint:        endpoint,
+		Region:          env("S3_TEST_REGION", "us-east-1"),
+		Bucket:          env("S3_TEST_BUCKET", "nova-cdn-test"),
+		AccessKeyID:     env("S3_TEST_ACCESS_KEY", "minioadmin"),
+		SecretAccessKey: env("S3_TEST_SECRET_KEY", "minioadmin"),
+		Prefix:          prefix,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	ctx := context.Background()
+	exists, err := s.client.BucketExists(ctx, s.bucket)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !exists {
+		if err := s.client.MakeBucket(ctx, s.bucket, minio.MakeBucketOptions{Region: env("S3_TEST_REGION", "us-east-1")}); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	keys := []string{"images/gallery/a.jpg", "/images/gallery/b.jpg", "files/c.txt"}
+	t.Cleanup(func() {
+		for _, key := range keys {
+			s.Delete(key)
+		}
+	})
+
+	for _, key := range keys {
+		put(t, s, key, key)
+	}
+
+	for _, key := range keys {
+		if got := read(t, s, key); got != key {
+			t.Errorf("Get(%q): got %q", key, got)
+		}
+
+		info, err := s.Stat(key)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if want := strings.TrimPrefix(key, "/"); info.Key != want || info.Size != int64(len(key)) || info.ContentType != "text/plain" {
+			t.Errorf("Stat(%q): got %+v", key, info)
+		}
+	}
+
+	objects, err := s.List("images/")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(objects) != 2 {
+		t.Errorf("got %+v, want the 2 images", objects)
+	}
+
+	if got, want := s.URL("images/gallery/a.jpg"), "/"+s.bucket+"/"+prefix+"images/gallery/a.jpg"; !strings.HasSuffix(got, want) {
+		t.Errorf("got URL %q, want it to end with %q", got, want)
+	}
+
+	if _, err := s.Get("images/missing.jpg"); !errors.Is(err, ErrNotFound) {
+		t.Errorf("Get: got %v, want ErrNotFound", err)
+	}
+	if _, err := s.Stat("images/missing.jpg"); !errors.Is(err, ErrNotFound) {
+		t.Errorf("Stat: got %v, want ErrNotFound", err)
+	}
+	if err := s.Delete("images/missing.jpg"); !errors.Is(err, ErrNotFound) {
+		t.Errorf("Delete: got %v, want ErrNotFound", err)
+	}
+
+	if err := s.Delete("files/c.txt"); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := s.Stat("files/c.txt"); !errors.Is(err, ErrNotFound) {
+		t.Errorf("got %v after deleting, want ErrNotFound", err)
+	}
+}
@@ -0,0 +1,74 @@
+package storage
+
+import (
+	"fmt"
+	"io"
+	"nova-cdn/internal/config"
+	"os"
+)
+
+// Staging is a scratch local disk for one unit of work. Files are written
+// there first and only promoted to their final disk once they are all ready,
+// so a failure half way leaves nothing behind but the staging directory.
+type Staging struct {
+	*LocalStorage
+	written []string
+}
+
+func NewStaging() (*Staging, error) {
+	if err := os.MkdirAll(config.StagingDir, 0755); err != nil {
+		return nil, fmt.Errorf("failed to create staging directory: %w", err)
+	}
+
+	root, err := os.MkdirTemp(config.StagingDir, "stage-*")
+	if err != nil {
+		return nil, fmt.Errorf("failed to create staging directory: %w", err)
+	}
+
+	return &Staging{LocalStorage: NewLocalStorage(root, "")}, nil
+}
+
+func (s *Staging) Put(key string, r io.Reader, size int64, contentType string) error {
+	if err := s.LocalStorage.Put(key, r, size, contentType); err != nil {
+		return err
+	}
+
+	s.written = append(s.written, key)
+	return nil
+}
+
+// Promote copies the staged files to dest under the same keys. The keys
+// already written to dest are returned even on failure so the caller can
+// undo them.
+func (s *Staging) Promote(dest Storage) ([]string, error) {
+	var promoted []string
+
+	for _, key := range s.written {
+		if err := s.promote(dest, key); err != nil {
+			return promoted, fmt.Errorf("failed to promote %s: %w", key, err)
+		}
+		promoted = append(promoted, key)
+	}
+
+	return promoted, nil
+}
+
+func (s *Staging) promote(dest Storage, key string) error {
+	obj, err := s.Stat(key)
+	if err != nil {
+		return err
+	}
+
+	r, err := s.Get(key)
+	if err != nil {
+		return err
+	}
+	defer r.Close()
+
+	return dest.Put(key, r, obj.Size, obj.ContentType)
+}
+
+// Cleanup removes the staging directory and everything left in it.
+func (s *Staging) Cleanup() error {
+	return os.RemoveAll(s.Root)
+}
@@ -0,0 +1,100 @@
+package storage
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"nova-cdn/internal/config"
+	"time"
+)
+
+var ErrNotFound = errors.New("file not found")
+
+type Object struct {
+	Key         string
+	Size        int64
+	ContentType string
+	ModTime     time.Time
+}
+
+// Storage is implemented by every disk that can hold gallery files. Keys are
+// slash separated paths relative to the disk root, e.g. "images/gallery/a.jpg".
+type Storage interface {
+	Put(key string, r io.Reader, size int64, contentType string) error
+	Get(key string) (io.ReadSeekCloser, error)
+	Delete(key string) error
+	Stat(key string) (*Object, error)
+	List(prefix string) ([]Object, error)
+	URL(key string) string
+}
+
+// Disks holds the public disk, served as-is from the CDN, and the private
+// disk, which is only reachable through signed URLs.
+type Disks struct {
+	Public  Storage
+	Private Storage
+}
+
+func (d *Disks) For(isPrivate bool) Storage {
+	if isPrivate {
+		return d.Private
+	}
+	return d.Public
+}
+
+var disks *Disks
+
+func New(driver string, private bool) (Storage, error) {
+	switch driver {
+	case "local", "public":
+		if private {
+			return NewLocalStorage(config.FilesystemPrivateRoot, ""), nil
+		}
+		return NewLocalStorage(config.FilesystemRoot, config.AppURL), nil
+	case "s3":
+		cfg := S3Config{
+			Endpoint:        config.AwsEndpoint,
+			Region:          config.AwsDefaultRegion,
+			Bucket:          config.AwsBucket,
+			AccessKeyID:     config.AwsAccessKeyID,
+			SecretAccessKey: config.AwsSecretAccessKey,
+			UseSSL:          config.AwsUseSSL,
+			URL:             config.AwsURL,
+		}
+
+		// Private files get a bucket of their own, a prefix of the public
+		// bucket would be as readable as the rest of it.
+		if private {
+			if config.AwsPrivateBucket == "" {
+				return nil, fmt.Errorf("AWS_PRIVATE_BUCKET is required by the s3 disk for private files")
+			}
+			cfg.URL = ""
+			cfg.Bucket = config.AwsPrivateBucket
+		}
+
+		return NewS3Storage(cfg)
+	default:
+		return nil, fmt.Errorf("unsupported filesystem disk: %s", driver)
+	}
+}
+
+func ConnectStorage() {
+	public, err := New(config.FilesystemDisk, false)
+	if err != nil {
+		log.Fatal("Failed to initialize storage:", err)
+	}
+
+	private, err := New(config.FilesystemDisk, true)
+	if err != nil {
+		log.Fatal("Failed to initialize private storage:", err)
+	}
+
+	disks = &Disks{Public: public, Private: private}
+
+	log.Printf("Storage disk %q initialized successfully!\n", config.FilesystemDisk)
+}
+
+func GetStorage() *Disks {
+	return disks
+}
@@ -0,0 +1,316 @@
+package tus
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"log"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+var (
+	ErrNotFound       = errors.New("upload not found")
+	ErrOffsetMismatch = errors.New("upload offset mismatch")
+	ErrTooLarge       = errors.New("chunk exceeds upload length")
+	ErrCompleted      = errors.New("upload is already completed")
+)
+
+// Upload is the state of a resumable upload, persisted next to its data as
+// <id>.info while the chunks are appended to <id>.bin.
+type Upload struct {
+	ID        string            `json:"id"`
+	UserID    uint              `json:"user_id"`
+	Length    int64             `json:"length"`
+	Offset    int64             `json:"offset"`
+	Metadata  map[string]string `json:"metadata"`
+	GroupCode string            `json:"group_code,omitempty"`
+	CreatedAt time.Time         `json:"created_at"`
+	ExpiresAt time.Time         `json:"expires_at"`
+}
+
+func (u *Upload) IsComplete() bool {
+	return u.Offset == u.Length
+}
+
+// Finisher stores the data of a completed upload and returns the group code
+// it was stored under.
+type Finisher func(upload *Upload) (string, error)
+
+type FileStore struct {
+	Dir        string
+	Expiration time.Duration
+
+	mu    sync.Mutex
+	locks map[string]*sync.Mutex
+}
+
+func NewFileStore(dir string, expiration time.Duration) (*FileStore, error) {
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return nil, err
+	}
+
+	return &FileStore{
+		Dir:        dir,
+		Expiration: expiration,
+		locks:      make(map[string]*sync.Mutex),
+	}, nil
+}
+
+func (s *FileStore) Create(userID uint, length int64, metadata map[string]string) (*Upload, error) {
+	id := strings.ReplaceAll(uuid.NewString(), "-", "")
+	now := time.Now()
+
+	upload := &Upload{
+		ID:        id,
+		UserID:    userID,
+		Length:    length,
+		Metadata:  metadata,
+		CreatedAt: now,
+		ExpiresAt: now.Add(s.Expiration),
+	}
+
+	file, err := os.OpenFile(s.binPath(id), os.O_CREATE|os.O_EXCL|os.O_WRONLY, 0644)
+	if err != nil {
+		return nil, err
+	}
+	file.Close()
+
+	if err := s.save(upload); err != nil {
+		os.Remove(s.binPath(id))
+		return nil, err
+	}
+
+	return upload, nil
+}
+
+func (s *FileStore) Get(id string) (*Upload, error) {
+	if !isValidID(id) {
+		return nil, ErrNotFound
+	}
+
+	data, err := os.ReadFile(s.infoPath(id))
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil, ErrNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	var upload Upload
+	if err := json.Unmarshal(data, &upload); err != nil {
+		return nil, err
+	}
+
+	return &upload, nil
+}
+
+// Append writes a chunk at the given offset and, once the upload is complete,
+// hands it to finish. Both are serialized per upload, so concurrent PATCH
+// requests for the same upload cannot interleave nor finish it twice.
+func (s *FileStore) Append(id string, offset int64, chunk io.Reader, finish Finisher) (*Upload, error) {
+	lock := s.lock(id)
+	lock.Lock()
+	defer lock.Unlock()
+
+	upload, err := s.Get(id)
+	if err != nil {
+		return nil, err
+	}
+
+	if upload.GroupCode != "" {
+		return upload, ErrCompleted
+	}
+
+	if upload.Offset != offset {
+		return upload, ErrOffsetMismatch
+	}
+
+	file, err := os.OpenFile(s.binPath(id), os.O_WRONLY, 0644)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	if _, err := file.Seek(offset, io.SeekStart); err != nil {
+		return nil, err
+	}
+
+	remaining := upload.Length - upload.Offset
+	written, err := io.Copy(file, io.LimitReader(chunk, remaining+1))
+	if written > remaining {
+		file.Truncate(upload.Offset)
+		return upload, ErrTooLarge
+	}
+
+	// Keep whatever part of the chunk made it to disk, the client resumes
+	// from the offset reported by the next HEAD request.
+	upload.Offset += written
+	upload.ExpiresAt = time.Now().Add(s.Expiration)
+
+	if saveErr := s.save(upload); saveErr != nil {
+		return nil, saveErr
+	}
+
+	if err != nil || !upload.IsComplete() {
+		return upload, err
+	}
+
+	groupCode, err := finish(upload)
+	if err != nil {
+		return upload, err
+	}
+
+	if err := s.complete(upload, groupCode); err != nil {
+		log.Printf("Failed to clean up completed upload %s: %v\n", upload.ID, err)
+	}
+
+	return upload, nil
+}
+
+func (s *FileStore) Open(id string) (*os.File, error) {
+	return os.Open(s.binPath(id))
+}
+
+// complete drops the uploaded data and remembers the group code it was stored
+// under, so HEAD requests keep working until the upload expires.
+func (s *FileStore) complete(upload *Upload, groupCode string) error {
+	upload.GroupCode = groupCode
+
+	if err := s.save(upload); err != nil {
+		return err
+	}
+
+	return os.Remove(s.binPath(upload.ID))
+}
+
+// Delete removes an upload once no request is appending to or finishing it.
+func (s *FileStore) Delete(id string) error {
+	if !isValidID(id) {
+		return ErrNotFound
+	}
+
+	lock := s.lock(id)
+	lock.Lock()
+	defer lock.Unlock()
+
+	return s.remove(id)
+}
+
+// CollectGarbage removes every upload that expired, complete or not. Uploads
+// busy with a request are left for the next run.
+func (s *FileStore) CollectGarbage() (int, error) {
+	entries, err := os.ReadDir(s.Dir)
+	if err != nil {
+		return 0, err
+	}
+
+	removed := 0
+
+	for _, entry := range entries {
+		id, ok := strings.CutSuffix(entry.Name(), ".info")
+		if !ok || !isValidID(id) {
+			continue
+		}
+
+		lock := s.lock(id)
+		if !lock.TryLock() {
+			continue
+		}
+
+		// Read the state under the lock, a request may just have extended it.
+		upload, err := s.Get(id)
+		if err != nil || time.Now().After(upload.ExpiresAt) {
+			if err := s.remove(id); err == nil {
+				removed++
+			}
+		}
+
+		lock.Unlock()
+	}
+
+	return removed, nil
+}
+
+// remove deletes the files of an upload, the caller holds its lock.
+func (s *FileStore) remove(id string) error {
+	os.Remove(s.binPath(id))
+
+	err := os.Remove(s.infoPath(id))
+	if errors.Is(err, fs.ErrNotExist) {
+		return ErrNotFound
+	}
+
+	s.mu.Lock()
+	delete(s.locks, id)
+	s.mu.Unlock()
+
+	return err
+}
+
+func (s *FileStore) RunGarbageCollector(interval time.Duration) {
+	for {
+		removed, err := s.CollectGarbage()
+		if err != nil {
+			log.Println("Failed to clean up expired uploads:", err)
+		} else if removed > 0 {
+			log.Printf("Removed %d expired uploads\n", removed)
+		}
+
+		time.Sleep(interval)
+	}
+}
+
+func (s *FileStore) save(upload *Upload) error {
+	data, err := json.Marshal(upload)
+	if err != nil {
+		return err
+	}
+
+	tmp := s.infoPath(upload.ID) + ".tmp"
+	if err := os.WriteFile(tmp, data, 0644); err != nil {
+		return fmt.Errorf("failed to save upload state: %w", err)
+	}
+
+	return os.Rename(tmp, s.infoPath(upload.ID))
+}
+
+func (s *FileStore) lock(id string) *sync.Mutex {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	lock, ok := s.locks[id]
+	if !ok {
+		lock = &sync.Mutex{}
+		s.locks[id] = lock
+	}
+	return lock
+}
+
+func (s *FileStore) infoPath(id string) string {
+	return filepath.Join(s.Dir, id+".info")
+}
+
+func (s *FileStore) binPath(id string) string {
+	return filepath.Join(s.Dir, id+".bin")
+}
+
+func isValidID(id string) bool {
+	if len(id) != 32 {
+		return false
+	}
+
+	for _, r := range id {
+		if !strings.ContainsRune("0123456789abcdef", r) {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,185 @@
+package tus
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestAppendFinishesOnce(t *testing.T) {
+	store, err := NewFileStore(t.TempDir(), time.Hour)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	upload, err := store.Create(1, 4, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var finished atomic.Int32
+	finish := func(upload *Upload) (string, error) {
+		finished.Add(1)
+		// Give the concurrent request time to reach the store.
+		time.Sleep(50 * time.Millisecond)
+		return "GL-0001", nil
+	}
+
+	var wg sync.WaitGroup
+	errs := make([]error, 2)
+
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		_, errs[0] = store.Append(upload.ID, 0, strings.NewReader("data"), finish)
+	}()
+
+	// An empty PATCH at offset == length, racing the last chunk.
+	time.Sleep(10 * time.Millisecond)
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		_, errs[1] = store.Append(upload.ID, 4, strings.NewReader(""), finish)
+	}()
+
+	wg.Wait()
+
+	if got := finished.Load(); got != 1 {
+		t.Fatalf("finish ran %d times, want 1", got)
+	}
+	if errs[0] != nil {
+		t.Fatalf("last chunk: %v", errs[0])
+	}
+	if !errors.Is(errs[1], ErrCompleted) {
+		t.Fatalf("concurrent request: got %v, want ErrCompleted", errs[1])
+	}
+
+	stored, err := store.Get(upload.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if stored.GroupCode != "GL-0001" {
+		t.Fatalf("got group code %q, want GL-0001", stored.GroupCode)
+	}
+}
+
+func TestAppendKeepsUploadWhenFinishFails(t *testing.T) {
+	store, err := NewFileStore(t.TempDir(), time.Hour)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	upload, err := store.Create(1, 4, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	failure := errors.New("storage is down")
+	_, err = store.Append(upload.ID, 0, strings.NewReader("data"), func(*Upload) (string, error) {
+		return "", failure
+	})
+	if !errors.Is(err, failure) {
+		t.Fatalf("got %v, want the finish error", err)
+	}
+
+	// The client retries with an empty PATCH at the final offset.
+	retried, err := store.Append(upload.ID, 4, strings.NewReader(""), func(*Upload) (string, error) {
+		return "GL-0002", nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if retried.GroupCode != "GL-0002" {
+		t.Fatalf("got group code %q, want GL-0002", retried.GroupCode)
+	}
+}
+
+func TestDeleteWaitsForFinish(t *testing.T) {
+	store, err := NewFileStore(t.TempDir(), time.Hour)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	upload, err := store.Create(1, 4, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	deleted := make(chan error, 1)
+	_, err = store.Append(upload.ID, 0, strings.NewReader("data"), func(upload *Upload) (string, error) {
+		go func() { deleted <- store.Delete(upload.ID) }()
+
+		// Give the DELETE time to reach the store, then read the data as
+		// the gallery service would.
+		time.Sleep(50 * time.Millisecond)
+		return "GL-0001", readUpload(store, upload.ID, "data")
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if err := <-deleted; err != nil {
+		t.Fatalf("Delete: %v", err)
+	}
+	if _, err := store.Get(upload.ID); !errors.Is(err, ErrNotFound) {
+		t.Fatalf("got %v after deleting, want ErrNotFound", err)
+	}
+}
+
+func TestCollectGarbageSkipsBusyUploads(t *testing.T) {
+	store, err := NewFileStore(t.TempDir(), time.Millisecond)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	upload, err := store.Create(1, 4, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	_, err = store.Append(upload.ID, 0, strings.NewReader("data"), func(upload *Upload) (string, error) {
+		// The upload expired while it was being finished.
+		time.Sleep(10 * time.Millisecond)
+
+		collected := make(chan int, 1)
+		go func() {
+			removed, _ := store.CollectGarbage()
+			collected <- removed
+		}()
+		if removed := <-collected; removed != 0 {
+			return "", errors.New("the busy upload was collected")
+		}
+
+		return "GL-0001", readUpload(store, upload.ID, "data")
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	time.Sleep(10 * time.Millisecond)
+	if removed, err := store.CollectGarbage(); err != nil || removed != 1 {
+		t.Fatalf("collected %d uploads once idle: %v", removed, err)
+	}
+}
+
+func readUpload(store *FileStore, id, want string) error {
+	file, err := store.Open(id)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	data, err := io.ReadAll(file)
+	if err != nil {
+		return err
+	}
+	if string(data) != want {
+		return fmt.Errorf("got %q, want %q", data, want)
+	}
+	return nil
+}
@@ -0,0 +1,103 @@
+package worker
+
+import (
+	"errors"
+	"log"
+	"nova-cdn/internal/config"
+	"nova-cdn/internal/models"
+	"nova-cdn/internal/repositories"
+	"nova-cdn/internal/service"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+const (
+	maxRetryBackoff = time.Hour
+	staleJobTimeout = 15 * time.Minute
+)
+
+// ImagePool is a bounded pool of workers that generates optimized versions for
+// the jobs queued by GalleryService.Upload.
+type ImagePool struct {
+	JobRepo        *repositories.ImageJobRepository
+	GalleryService service.GalleryService
+	Concurrency    int
+	PollInterval   time.Duration
+	RetryBackoff   time.Duration
+}
+
+func NewImagePool(db *gorm.DB) *ImagePool {
+	return &ImagePool{
+		JobRepo:        repositories.NewImageJobRepository(db),
+		GalleryService: service.NewGalleryService(db),
+		Concurrency:    config.WorkerConcurrency,
+		PollInterval:   config.WorkerPollInterval,
+		RetryBackoff:   config.JobRetryBackoff,
+	}
+}
+
+func (p *ImagePool) Start() {
+	released, err := p.JobRepo.ReleaseStale(staleJobTimeout)
+	if err != nil {
+		log.Println("Failed to release stale image jobs:", err)
+	} else if released > 0 {
+		log.Printf("Released %d stale image jobs\n", released)
+	}
+
+	for i := 0; i < p.Concurrency; i++ {
+		go p.run()
+	}
+
+	log.Printf("Image worker pool started with %d workers\n", p.Concurrency)
+}
+
+func (p *ImagePool) run() {
+	for {
+		job, err := p.JobRepo.ClaimNext()
+
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			time.Sleep(p.PollInterval)
+			continue
+		}
+
+		if err != nil {
+			log.Println("Failed to claim image job:", err)
+			time.Sleep(p.PollInterval)
+			continue
+		}
+
+		p.process(job)
+	}
+}
+
+func (p *ImagePool) process(job *models.ImageJob) {
+	start := time.Now()
+
+	err := p.GalleryService.ProcessVariants(job)
+	if err == nil {
+		if err := p.JobRepo.MarkDone(job); err != nil {
+			log.Printf("Failed to mark image job %d as done: %v\n", job.ID, err)
+		}
+		log.Printf("Image job %d (%s) done in %s\n", job.ID, job.GroupCode, time.Since(start).Round(time.Millisecond))
+		return
+	}
+
+	log.Printf("Image job %d (%s) failed on attempt %d/%d: %v\n", job.ID, job.GroupCode, job.Attempts, job.MaxAttempts, err)
+
+	if err := p.JobRepo.MarkFailed(job, err, p.backoff(job.Attempts)); err != nil {
+		log.Printf("Failed to update image job %d: %v\n", job.ID, err)
+	}
+}
+
+// backoff doubles the configured delay for every attempt already made.
+func (p *ImagePool) backoff(attempts int) time.Duration {
+	delay := p.RetryBackoff
+	for i := 1; i < attempts; i++ {
+		delay *= 2
+		if delay >= maxRetryBackoff {
+			return maxRetryBackoff
+		}
+	}
+	return delay
+}
@@ -0,0 +1,74 @@
+package worker
+
+import (
+	"log"
+	"nova-cdn/internal/config"
+	"nova-cdn/internal/repositories"
+	"nova-cdn/internal/service"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+const trashPurgeBatchSize = 100
+
+// TrashPurger permanently deletes galleries that have been in the trash for
+// longer than the retention period, together with their files.
+type TrashPurger struct {
+	GalleryRepo    *repositories.GalleryRepository
+	GalleryService service.GalleryService
+	Retention      time.Duration
+	Interval       time.Duration
+}
+
+func NewTrashPurger(db *gorm.DB) *TrashPurger {
+	return &TrashPurger{
+		GalleryRepo:    repositories.NewGalleryRepository(db),
+		GalleryService: service.NewGalleryService(db),
+		Retention:      config.TrashRetention,
+		Interval:       config.TrashPurgeInterval,
+	}
+}
+
+func (p *TrashPurger) Start() {
+	if p.Retention <= 0 {
+		log.Println("Trash purge disabled, trashed galleries are kept until force deleted")
+		return
+	}
+
+	go func() {
+		for {
+			p.Purge()
+			time.Sleep(p.Interval)
+		}
+	}()
+
+	log.Printf("Trash purger started, retention %s\n", p.Retention)
+}
+
+// Purge deletes every gallery trashed before the retention cutoff, in
+// batches, and returns how many rows were removed.
+func (p *TrashPurger) Purge() int {
+	cutoff := time.Now().Add(-p.Retention)
+	purged := 0
+
+	for {
+		galleries, err := p.GalleryRepo.ForceDeleteTrashedBefore(cutoff, trashPurgeBatchSize)
+		if err != nil {
+			log.Println("Failed to purge trashed galleries:", err)
+			break
+		}
+
+		p.GalleryService.RemoveFiles(galleries)
+		purged += len(galleries)
+
+		if len(galleries) < trashPurgeBatchSize {
+			break
+		}
+	}
+
+	if purged > 0 {
+		log.Printf("Purged %d trashed galleries\n", purged)
+	}
+	return purged
+}
@@ -2,29 +2,54 @@ package utils
 
 import (
 	"fmt"
-	"math/rand"
 	"time"
 
+	"nova-cdn/internal/models"
 	"nova-cdn/internal/repositories"
 )
 
-func GetCode(repo *repositories.GenerateRepository, alias string, isNotPreview bool) string {
-	gen, err := repo.FindByAlias(alias)
-	if err != nil || gen == nil {
-		return fmt.Sprintf("ER-%05d", rand.Intn(90000)+10000)
+// GetCode returns the next code of the alias sequence. When isNotPreview is
+// set the sequence row is locked, advanced and saved in one transaction, so
+// concurrent callers never get the same code.
+func GetCode(repo *repositories.GenerateRepository, alias string, isNotPreview bool) (string, error) {
+	if !isNotPreview {
+		gen, err := repo.FindByAlias(alias)
+		if err != nil {
+			return "", fmt.Errorf("code sequence %q not found: %w", alias, err)
+		}
+		return nextCode(gen, time.Now()), nil
 	}
 
-	now := time.Now()
-	date := now.Format("060102")
-	separator := gen.Separator
+	var code string
+
+	err := repo.Transaction(func(txRepo *repositories.GenerateRepository) error {
+		gen, err := txRepo.FindByAliasForUpdate(alias)
+		if err != nil {
+			return fmt.Errorf("code sequence %q not found: %w", alias, err)
+		}
+
+		code = nextCode(gen, time.Now())
+		gen.Queue += 1
+
+		return txRepo.UpdateSequence(gen)
+	})
 
-	separatorTime, err := time.Parse("060102", separator)
 	if err != nil {
-		separatorTime = now
+		return "", err
 	}
-	separatorStr := separatorTime.Format("060102")
 
-	if gen.Queue == 9999 || date[:4] != separatorStr[:4] {
+	return code, nil
+}
+
+// nextCode formats the code for the current queue, resetting the sequence on
+// gen when a new month starts or it has no valid separator yet, as seeded by
+// the migrations. The queue takes more than 4 digits past 9999 rather than
+// wrapping, so codes never repeat within a month.
+func nextCode(gen *models.Generate, now time.Time) string {
+	date := now.Format("060102")
+
+	separatorTime, err := time.Parse("060102", gen.Separator)
+	if err != nil || separatorTime.Format("0601") != date[:4] {
 		gen.Queue = 1
 		gen.Separator = date
 	}
@@ -38,10 +63,5 @@ func GetCode(repo *repositories.GenerateRepository, alias string, isNotPreview b
 		queue = queue + *gen.Suffix
 	}
 
-	if isNotPreview {
-		gen.Queue += 1
-		repo.Update(gen)
-	}
-
 	return queue
 }
@@ -0,0 +1,92 @@
+package utils
+
+import (
+	"testing"
+	"time"
+
+	"nova-cdn/internal/migrations"
+	"nova-cdn/internal/models"
+	"nova-cdn/internal/repositories"
+
+	"github.com/glebarez/sqlite"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+)
+
+func TestNextCode(t *testing.T) {
+	prefix := "GL-"
+	now := time.Date(2026, 10, 17, 12, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		name          string
+		queue         int
+		separator     string
+		want          string
+		wantQueue     int
+		wantSeparator string
+	}{
+		{"same month", 42, "261001", "GL-2610004217", 42, "261001"},
+		{"last 4 digit code", 9999, "261001", "GL-2610999917", 9999, "261001"},
+		{"past 9999", 10000, "261001", "GL-26101000017", 10000, "261001"},
+		{"new month", 10000, "260930", "GL-2610000117", 1, "261017"},
+		{"new year", 42, "251017", "GL-2610000117", 1, "261017"},
+		{"empty separator", 42, "", "GL-2610000117", 1, "261017"},
+		{"invalid separator", 42, "garbage", "GL-2610000117", 1, "261017"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			gen := &models.Generate{Prefix: &prefix, Queue: tt.queue, Separator: tt.separator}
+
+			if code := nextCode(gen, now); code != tt.want {
+				t.Errorf("got code %q, want %q", code, tt.want)
+			}
+			if gen.Queue != tt.wantQueue {
+				t.Errorf("got queue %d, want %d", gen.Queue, tt.wantQueue)
+			}
+			if gen.Separator != tt.wantSeparator {
+				t.Errorf("got separator %q, want %q", gen.Separator, tt.wantSeparator)
+			}
+		})
+	}
+}
+
+func TestGetCodePersistsSeparator(t *testing.T) {
+	db, err := gorm.Open(sqlite.Open(":memory:"), &gorm.Config{Logger: logger.Default.LogMode(logger.Silent)})
+	if err != nil {
+		t.Fatal(err)
+	}
+	sqlDB, _ := db.DB()
+	sqlDB.SetMaxOpenConns(1)
+
+	migrator, err := migrations.New(db)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := migrator.Up(); err != nil {
+		t.Fatal(err)
+	}
+
+	// The migrations seed the sequence with an empty separator.
+	repo := repositories.NewGenerateRepository(db)
+	var codes []string
+	for i := 0; i < 2; i++ {
+		code, err := GetCode(repo, "gallery_group", true)
+		if err != nil {
+			t.Fatal(err)
+		}
+		codes = append(codes, code)
+	}
+
+	if codes[0] == codes[1] {
+		t.Fatalf("got the same code %q twice", codes[0])
+	}
+
+	gen, err := repo.FindByAlias("gallery_group")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if gen.Separator != time.Now().Format("060102") || gen.Queue != 3 {
+		t.Errorf("got separator %q and queue %d, want today and 3", gen.Separator, gen.Queue)
+	}
+}
@@ -0,0 +1,92 @@
+package utils
+
+import (
+	"io"
+	"mime"
+	"path"
+	"strings"
+)
+
+// FileExtensions maps the content types of non-image uploads to the extension
+// used when storing them.
+var FileExtensions = map[string]string{
+	"application/pdf":  ".pdf",
+	"application/zip":  ".zip",
+	"application/json": ".json",
+	"video/mp4":        ".mp4",
+	"video/webm":       ".webm",
+	"audio/mpeg":       ".mp3",
+	"audio/wave":       ".wav",
+	"audio/ogg":        ".ogg",
+	"text/csv":         ".csv",
+	"text/plain":       ".txt",
+	"application/vnd.openxmlformats-officedocument.wordprocessingml.document":   ".docx",
+	"application/vnd.openxmlformats-officedocument.spreadsheetml.sheet":         ".xlsx",
+	"application/vnd.openxmlformats-officedocument.presentationml.presentation": ".pptx",
+	"application/epub+zip": ".epub",
+}
+
+// zipContainers are formats sniffed as plain zip archives.
+var zipContainers = map[string]bool{
+	"application/vnd.openxmlformats-officedocument.wordprocessingml.document":   true,
+	"application/vnd.openxmlformats-officedocument.spreadsheetml.sheet":         true,
+	"application/vnd.openxmlformats-officedocument.presentationml.presentation": true,
+	"application/epub+zip": true,
+}
+
+type DetectedFile struct {
+	MimeType string
+	Ext      string
+	Format   string
+}
+
+// DetectFileType sniffs the content type of r. Content sniffing can't tell a
+// CSV from plain text or a DOCX from a zip archive, so in those cases only the
+// extension of fileName picks the more specific type.
+func DetectFileType(r io.ReadSeeker, fileName string) (*DetectedFile, error) {
+	mimeType, err := DetectContentType(r)
+	if err != nil {
+		return nil, err
+	}
+
+	named := TypeByExtension(path.Ext(fileName))
+
+	switch {
+	case mimeType == "text/plain" && strings.HasPrefix(named, "text/"):
+		mimeType = named
+	case mimeType == "application/zip" && zipContainers[named]:
+		mimeType = named
+	}
+
+	ext, ok := ImageExtensions[mimeType]
+	if !ok {
+		ext, ok = FileExtensions[mimeType]
+	}
+	if !ok {
+		if exts, _ := mime.ExtensionsByType(mimeType); len(exts) > 0 {
+			ext = exts[0]
+		}
+	}
+
+	format := strings.TrimPrefix(ext, ".")
+	if _, isImage := ImageExtensions[mimeType]; isImage {
+		format = FormatFromMimeType(mimeType)
+	}
+
+	return &DetectedFile{MimeType: mimeType, Ext: ext, Format: format}, nil
+}
+
+// TypeByExtension returns the content type of an extension without
+// parameters, preferring FileExtensions over the system table.
+func TypeByExtension(ext string) string {
+	ext = strings.ToLower(ext)
+
+	for mimeType, known := range FileExtensions {
+		if known == ext {
+			return mimeType
+		}
+	}
+
+	mimeType, _, _ := strings.Cut(mime.TypeByExtension(ext), ";")
+	return mimeType
+}
@@ -0,0 +1,187 @@
+package utils
+
+import (
+	"context"
+	"fmt"
+	"image"
+	"image/jpeg"
+	"image/png"
+	"io"
+	"os"
+	"os/exec"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+
+	"nova-cdn/internal/config"
+
+	"github.com/HugoSmits86/nativewebp"
+)
+
+type ImageFormat struct {
+	Name     string
+	Ext      string
+	MimeType string
+	Encode   func(w io.Writer, img image.Image, quality int) error
+}
+
+var ImageFormats = map[string]ImageFormat{
+	"jpeg": {Name: "jpeg", Ext: ".jpg", MimeType: "image/jpeg", Encode: encodeJPEG},
+	"png":  {Name: "png", Ext: ".png", MimeType: "image/png", Encode: encodePNG},
+	"webp": {Name: "webp", Ext: ".webp", MimeType: "image/webp", Encode: encodeWebP},
+	"avif": {Name: "avif", Ext: ".avif", MimeType: "image/avif", Encode: encodeAVIF},
+}
+
+// ModernFormats lists the formats that are negotiated through the Accept
+// header, best first.
+var ModernFormats = []string{"avif", "webp"}
+
+var (
+	avifencPath string
+	avifencOnce sync.Once
+)
+
+// IsFormatAvailable reports whether images can be encoded in the given format
+// on this host. AVIF needs the avifenc binary from libavif to be installed.
+func IsFormatAvailable(format string) bool {
+	if _, ok := ImageFormats[format]; !ok {
+		return false
+	}
+
+	if format == "avif" {
+		avifencOnce.Do(func() {
+			avifencPath, _ = exec.LookPath("avifenc")
+		})
+		return avifencPath != ""
+	}
+
+	return true
+}
+
+func EncodeImage(w io.Writer, img image.Image, format string, quality int) error {
+	if !IsFormatAvailable(format) {
+		return fmt.Errorf("unsupported output format: %s", format)
+	}
+
+	if format == "jpeg" {
+		img = flatten(img)
+	}
+
+	return ImageFormats[format].Encode(w, img, quality)
+}
+
+func FormatFromMimeType(mimeType string) string {
+	for name, format := range ImageFormats {
+		if format.MimeType == mimeType {
+			return name
+		}
+	}
+	return strings.TrimPrefix(mimeType, "image/")
+}
+
+// NegotiateImageFormat picks the best of the available modern formats that the
+// Accept header explicitly lists, or returns fallback. Wildcards such as
+// image/* are not taken as support for a modern format.
+func NegotiateImageFormat(accept string, available []string, fallback string) string {
+	accepted := map[string]float64{}
+
+	for _, part := range strings.Split(accept, ",") {
+		fields := strings.Split(strings.TrimSpace(part), ";")
+		mediaType := strings.ToLower(strings.TrimSpace(fields[0]))
+		q := 1.0
+
+		for _, param := range fields[1:] {
+			param = strings.TrimSpace(param)
+			if strings.HasPrefix(param, "q=") {
+				if v, err := strconv.ParseFloat(strings.TrimPrefix(param, "q="), 64); err == nil {
+					q = v
+				}
+			}
+		}
+
+		accepted[mediaType] = q
+	}
+
+	candidates := []string{}
+	for _, format := range ModernFormats {
+		if q := accepted[ImageFormats[format].MimeType]; q > 0 && containsString(available, format) {
+			candidates = append(candidates, format)
+		}
+	}
+
+	if len(candidates) == 0 {
+		return fallback
+	}
+
+	sort.SliceStable(candidates, func(i, j int) bool {
+		return accepted[ImageFormats[candidates[i]].MimeType] > accepted[ImageFormats[candidates[j]].MimeType]
+	})
+
+	return candidates[0]
+}
+
+// HasAlpha reports whether the image has any non opaque pixel, in which case
+// variants are stored as PNG instead of JPEG.
+func HasAlpha(img image.Image) bool {
+	if opaque, ok := img.(interface{ Opaque() bool }); ok {
+		return !opaque.Opaque()
+	}
+	return true
+}
+
+func containsString(list []string, value string) bool {
+	for _, item := range list {
+		if item == value {
+			return true
+		}
+	}
+	return false
+}
+
+func encodeJPEG(w io.Writer, img image.Image, quality int) error {
+	return jpeg.Encode(w, img, &jpeg.Options{Quality: quality})
+}
+
+func encodePNG(w io.Writer, img image.Image, quality int) error {
+	encoder := png.Encoder{CompressionLevel: png.BestCompression}
+	return encoder.Encode(w, img)
+}
+
+func encodeWebP(w io.Writer, img image.Image, quality int) error {
+	return nativewebp.Encode(w, img, nil)
+}
+
+func encodeAVIF(w io.Writer, img image.Image, quality int) error {
+	input, err := os.CreateTemp("", "nova-cdn-*.png")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(input.Name())
+
+	if err := png.Encode(input, img); err != nil {
+		input.Close()
+		return err
+	}
+	input.Close()
+
+	output := strings.TrimSuffix(input.Name(), ".png") + ".avif"
+	defer os.Remove(output)
+
+	ctx, cancel := context.WithTimeout(context.Background(), config.ImageEncoderTimeout)
+	defer cancel()
+
+	cmd := exec.CommandContext(ctx, avifencPath, "-q", strconv.Itoa(quality), input.Name(), output)
+	if out, err := cmd.CombinedOutput(); err != nil {
+		return fmt.Errorf("avifenc failed: %w: %s", err, strings.TrimSpace(string(out)))
+	}
+
+	file, err := os.Open(output)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	_, err = io.Copy(w, file)
+	return err
+}
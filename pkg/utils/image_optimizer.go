@@ -1,11 +1,16 @@
 package utils
 
 import (
+	"bytes"
 	"fmt"
 	"image"
-	"image/jpeg"
+	_ "image/gif"
 	_ "image/png"
-	"os"
+	"io"
+	"log"
+	"nova-cdn/internal/config"
+	"nova-cdn/internal/storage"
+	"path"
 	"path/filepath"
 	"strings"
 
@@ -24,6 +29,10 @@ type ProcessedImage struct {
 	FilePath string
 	FileSize uint32
 	Size     string
+	Format   string
+	Checksum string
+	Width    int
+	Height   int
 }
 
 var ImageVersions = []ImageVersion{
@@ -32,56 +41,82 @@ var ImageVersions = []ImageVersion{
 	{Prefix: "large", Width: 1600, Quality: 65},
 }
 
-func ProcessImage(inputPath, outputDir, baseName string) ([]ProcessedImage, error) {
-	file, err := os.Open(inputPath)
-	if err != nil {
-		return nil, fmt.Errorf("failed to open input file: %w", err)
-	}
-	defer file.Close()
-
-	img, _, err := image.Decode(file)
+// ProcessImage stores every ImageVersion of src as JPEG (PNG when the source
+// has transparency) plus one file per configured modern format, e.g.
+// small-<name>.jpg, small-<name>.webp and small-<name>.avif. A modern format
+// is only kept when it comes out smaller than the JPEG or PNG of the same
+// version, which lossless WebP often doesn't for photos.
+func ProcessImage(store storage.Storage, src io.Reader, outputDir, baseName string) ([]ProcessedImage, error) {
+	img, _, err := image.Decode(src)
 	if err != nil {
 		return nil, fmt.Errorf("failed to decode image: %w", err)
 	}
 
-	var results []ProcessedImage
-
-	for _, version := range ImageVersions {
-		resized := resize.Resize(version.Width, 0, img, resize.Lanczos3)
-
-		versionFileName := fmt.Sprintf("%s-%s.jpg", version.Prefix, strings.TrimSuffix(baseName, filepath.Ext(baseName)))
-		versionFilePath := filepath.Join(outputDir, versionFileName)
+	baseFormat := "jpeg"
+	if HasAlpha(img) {
+		baseFormat = "png"
+	}
 
-		outFile, err := os.Create(versionFilePath)
-		if err != nil {
-			return nil, fmt.Errorf("failed to create output file: %w", err)
+	formats := []string{baseFormat}
+	for _, format := range config.ImageVariantFormats {
+		if format == baseFormat {
+			continue
 		}
 
-		err = jpeg.Encode(outFile, resized, &jpeg.Options{Quality: version.Quality})
-		outFile.Close()
-		if err != nil {
-			return nil, fmt.Errorf("failed to encode jpeg: %w", err)
+		if !IsFormatAvailable(format) {
+			log.Printf("Skipping %s variants: encoder is not available\n", format)
+			continue
 		}
 
-		info, err := os.Stat(versionFilePath)
-		if err != nil {
-			return nil, fmt.Errorf("failed to stat output file: %w", err)
-		}
+		formats = append(formats, format)
+	}
 
-		relPath := "images/gallery/" + versionFileName
+	var results []ProcessedImage
 
-		results = append(results, ProcessedImage{
-			FileName: versionFileName,
-			FilePath: relPath,
-			FileSize: uint32(info.Size()),
-			Size:     version.Prefix,
-		})
+	for _, version := range ImageVersions {
+		resized := resize.Resize(version.Width, 0, img, resize.Lanczos3)
+		bounds := resized.Bounds()
+
+		baseSize := 0
+
+		for _, format := range formats {
+			versionFileName := fmt.Sprintf("%s-%s%s", version.Prefix, strings.TrimSuffix(baseName, filepath.Ext(baseName)), ImageFormats[format].Ext)
+			versionFilePath := path.Join(outputDir, versionFileName)
+
+			var buf bytes.Buffer
+			if err := EncodeImage(&buf, resized, format, version.Quality); err != nil {
+				return nil, fmt.Errorf("failed to encode %s: %w", format, err)
+			}
+
+			fileSize := buf.Len()
+			if format == baseFormat {
+				baseSize = fileSize
+			} else if fileSize >= baseSize {
+				continue
+			}
+
+			checksum := ChecksumBytes(buf.Bytes())
+
+			if err := store.Put(versionFilePath, &buf, int64(fileSize), ImageFormats[format].MimeType); err != nil {
+				return nil, fmt.Errorf("failed to store output file: %w", err)
+			}
+
+			results = append(results, ProcessedImage{
+				FileName: versionFileName,
+				FilePath: versionFilePath,
+				FileSize: uint32(fileSize),
+				Size:     version.Prefix,
+				Format:   format,
+				Checksum: checksum,
+				Width:    bounds.Dx(),
+				Height:   bounds.Dy(),
+			})
+		}
 	}
 
 	return results, nil
 }
 
-func RemoveImageFiles(filePath string) error {
-	filePath = "public/" + filePath
-	return os.Remove(filePath)
+func RemoveImageFiles(store storage.Storage, filePath string) error {
+	return store.Delete(filePath)
 }
@@ -0,0 +1,81 @@
+package utils
+
+import (
+	"bytes"
+	"image"
+	"image/color"
+	"image/jpeg"
+	"math/rand"
+	"testing"
+
+	"nova-cdn/internal/config"
+	"nova-cdn/internal/storage"
+)
+
+func TestProcessImageKeepsSmallerModernFormats(t *testing.T) {
+	config.ImageVariantFormats = []string{"webp"}
+
+	flat := image.NewRGBA(image.Rect(0, 0, 64, 48))
+	noisy := image.NewRGBA(image.Rect(0, 0, 64, 48))
+	rng := rand.New(rand.NewSource(1))
+	for y := 0; y < 48; y++ {
+		for x := 0; x < 64; x++ {
+			flat.Set(x, y, color.RGBA{R: 200, G: 80, B: 40, A: 255})
+			noisy.Set(x, y, color.RGBA{R: uint8(rng.Intn(256)), G: uint8(rng.Intn(256)), B: uint8(rng.Intn(256)), A: 255})
+		}
+	}
+
+	tests := []struct {
+		name     string
+		img      image.Image
+		wantWebP bool
+	}{
+		// Lossless WebP beats JPEG on flat colours and loses on noise.
+		{"flat", flat, true},
+		{"noisy", noisy, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var src bytes.Buffer
+			if err := jpeg.Encode(&src, tt.img, &jpeg.Options{Quality: 100}); err != nil {
+				t.Fatal(err)
+			}
+
+			store := storage.NewLocalStorage(t.TempDir(), "")
+			results, err := ProcessImage(store, &src, "images", "photo.jpg")
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			jpegSizes := map[string]uint32{}
+			webps := 0
+			for _, result := range results {
+				if result.Format == "jpeg" {
+					jpegSizes[result.Size] = result.FileSize
+				}
+			}
+			for _, result := range results {
+				if result.Format != "webp" {
+					continue
+				}
+				webps++
+				if result.FileSize >= jpegSizes[result.Size] {
+					t.Errorf("%s: kept a %d bytes WebP next to a %d bytes JPEG", result.FileName, result.FileSize, jpegSizes[result.Size])
+				}
+			}
+
+			if len(jpegSizes) != len(ImageVersions) {
+				t.Fatalf("got %d JPEG variants, want %d", len(jpegSizes), len(ImageVersions))
+			}
+
+			want := 0
+			if tt.wantWebP {
+				want = len(ImageVersions)
+			}
+			if webps != want {
+				t.Errorf("got %d WebP variants, want %d", webps, want)
+			}
+		})
+	}
+}
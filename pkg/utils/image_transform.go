@@ -0,0 +1,116 @@
+package utils
+
+import (
+	"bytes"
+	"fmt"
+	"image"
+	"image/color"
+	"image/draw"
+	"io"
+	"net/url"
+	"strconv"
+
+	"github.com/nfnt/resize"
+)
+
+const (
+	MaxTransformDimension   = 4096
+	DefaultTransformFit     = "contain"
+	DefaultTransformQuality = 75
+)
+
+type TransformOptions struct {
+	Width   int
+	Height  int
+	Fit     string
+	Quality int
+	Format  string
+}
+
+var TransformFits = map[string]bool{
+	"cover":   true,
+	"contain": true,
+	"fill":    true,
+}
+
+// Canonical returns the options as a stable query string, used both as the
+// cache key and as the payload of transformation signatures.
+func (o TransformOptions) Canonical() string {
+	values := url.Values{}
+	values.Set("w", strconv.Itoa(o.Width))
+	values.Set("h", strconv.Itoa(o.Height))
+	values.Set("fit", o.Fit)
+	values.Set("q", strconv.Itoa(o.Quality))
+	values.Set("fm", o.Format)
+	return values.Encode()
+}
+
+func TransformImage(src io.Reader, opts TransformOptions) ([]byte, string, error) {
+	img, sourceFormat, err := image.Decode(src)
+	if err != nil {
+		return nil, "", fmt.Errorf("failed to decode image: %w", err)
+	}
+
+	format := opts.Format
+	if format == "" {
+		format = "jpeg"
+		if sourceFormat == "png" || sourceFormat == "gif" {
+			format = "png"
+		}
+	}
+
+	resized := resizeImage(img, opts.Width, opts.Height, opts.Fit)
+
+	var buf bytes.Buffer
+	if err := EncodeImage(&buf, resized, format, opts.Quality); err != nil {
+		return nil, "", fmt.Errorf("failed to encode %s: %w", format, err)
+	}
+
+	return buf.Bytes(), format, nil
+}
+
+func resizeImage(img image.Image, width, height int, fit string) image.Image {
+	bounds := img.Bounds()
+	srcW, srcH := bounds.Dx(), bounds.Dy()
+
+	if width == 0 && height == 0 {
+		return img
+	}
+
+	if width == 0 || height == 0 {
+		return resize.Resize(uint(width), uint(height), img, resize.Lanczos3)
+	}
+
+	switch fit {
+	case "fill":
+		return resize.Resize(uint(width), uint(height), img, resize.Lanczos3)
+	case "cover":
+		scale := max(float64(width)/float64(srcW), float64(height)/float64(srcH))
+		scaledW := max(width, int(float64(srcW)*scale+0.5))
+		scaledH := max(height, int(float64(srcH)*scale+0.5))
+		scaled := resize.Resize(uint(scaledW), uint(scaledH), img, resize.Lanczos3)
+
+		offsetX := (scaled.Bounds().Dx() - width) / 2
+		offsetY := (scaled.Bounds().Dy() - height) / 2
+
+		cropped := image.NewNRGBA(image.Rect(0, 0, width, height))
+		draw.Draw(cropped, cropped.Bounds(), scaled, scaled.Bounds().Min.Add(image.Pt(offsetX, offsetY)), draw.Src)
+		return cropped
+	default:
+		return resize.Thumbnail(uint(width), uint(height), img, resize.Lanczos3)
+	}
+}
+
+// flatten draws the image on a white background, JPEG has no alpha channel
+// and would otherwise render transparent pixels as black.
+func flatten(img image.Image) image.Image {
+	if opaque, ok := img.(interface{ Opaque() bool }); ok && opaque.Opaque() {
+		return img
+	}
+
+	bounds := img.Bounds()
+	result := image.NewRGBA(bounds)
+	draw.Draw(result, bounds, &image.Uniform{C: color.White}, image.Point{}, draw.Src)
+	draw.Draw(result, bounds, img, bounds.Min, draw.Over)
+	return result
+}
@@ -0,0 +1,111 @@
+package utils
+
+import (
+	"errors"
+	"fmt"
+	"image"
+	"io"
+	"net/http"
+	"strings"
+)
+
+var ErrUnsupportedImage = errors.New("unsupported image type")
+
+// ImageExtensions maps the sniffed content types to the extension used when
+// storing the file, so the client supplied filename never decides it.
+var ImageExtensions = map[string]string{
+	"image/jpeg": ".jpg",
+	"image/png":  ".png",
+	"image/gif":  ".gif",
+	"image/webp": ".webp",
+}
+
+type ImageLimits struct {
+	MaxWidth  int
+	MaxHeight int
+	MaxPixels int
+}
+
+type DetectedImage struct {
+	MimeType string
+	Ext      string
+	Width    int
+	Height   int
+}
+
+// DetectContentType sniffs the first 512 bytes of r and rewinds it.
+func DetectContentType(r io.ReadSeeker) (string, error) {
+	if _, err := r.Seek(0, io.SeekStart); err != nil {
+		return "", err
+	}
+
+	head := make([]byte, 512)
+	n, err := io.ReadFull(r, head)
+	if err != nil && err != io.ErrUnexpectedEOF && err != io.EOF {
+		return "", err
+	}
+
+	if _, err := r.Seek(0, io.SeekStart); err != nil {
+		return "", err
+	}
+
+	mimeType := http.DetectContentType(head[:n])
+	if i := strings.Index(mimeType, ";"); i >= 0 {
+		mimeType = mimeType[:i]
+	}
+	return mimeType, nil
+}
+
+// ValidateImage checks that r really is one of the supported image types: the
+// sniffed type must match the decoder that reads the header, the dimensions
+// must stay within limits before any pixel is allocated, and the whole image
+// must decode.
+func ValidateImage(r io.ReadSeeker, limits ImageLimits) (*DetectedImage, error) {
+	mimeType, err := DetectContentType(r)
+	if err != nil {
+		return nil, err
+	}
+
+	ext, ok := ImageExtensions[mimeType]
+	if !ok {
+		return nil, ErrUnsupportedImage
+	}
+
+	cfg, format, err := image.DecodeConfig(r)
+	if err != nil {
+		return nil, fmt.Errorf("uploaded file is not a valid image")
+	}
+
+	if "image/"+format != mimeType {
+		return nil, fmt.Errorf("uploaded file content does not match its image type")
+	}
+
+	if cfg.Width <= 0 || cfg.Height <= 0 {
+		return nil, fmt.Errorf("uploaded file is not a valid image")
+	}
+
+	if (limits.MaxWidth > 0 && cfg.Width > limits.MaxWidth) ||
+		(limits.MaxHeight > 0 && cfg.Height > limits.MaxHeight) ||
+		(limits.MaxPixels > 0 && cfg.Width*cfg.Height > limits.MaxPixels) {
+		return nil, fmt.Errorf("image dimensions %dx%d exceed the allowed limit", cfg.Width, cfg.Height)
+	}
+
+	if _, err := r.Seek(0, io.SeekStart); err != nil {
+		return nil, err
+	}
+
+	if _, _, err := image.Decode(r); err != nil {
+		return nil, fmt.Errorf("uploaded file could not be decoded as %s", format)
+	}
+
+	if _, err := r.Seek(0, io.SeekStart); err != nil {
+		return nil, err
+	}
+
+	return &DetectedImage{
+		MimeType: mimeType,
+		Ext:      ext,
+		Width:    cfg.Width,
+		Height:   cfg.Height,
+	}, nil
+}
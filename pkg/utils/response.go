@@ -38,13 +38,17 @@ type PaginatedResponse struct {
 	Meta    Meta        `json:"meta"`
 }
 
+// Meta describes a page of results. Offset pagination fills the page counts,
+// cursor pagination the next and previous cursors instead.
 type Meta struct {
-	TotalRecords int64 `json:"total_records"`
-	ItemsOnPage  int   `json:"items_on_page"`
-	PerPage      int   `json:"per_page"`
-	CurrentPage  int   `json:"current_page"`
-	TotalPages   int   `json:"total_pages"`
-	HasMorePages bool  `json:"has_more_pages"`
+	TotalRecords int64  `json:"total_records,omitempty"`
+	ItemsOnPage  int    `json:"items_on_page"`
+	PerPage      int    `json:"per_page"`
+	CurrentPage  int    `json:"current_page,omitempty"`
+	TotalPages   int    `json:"total_pages,omitempty"`
+	HasMorePages bool   `json:"has_more_pages"`
+	NextCursor   string `json:"next_cursor,omitempty"`
+	PrevCursor   string `json:"prev_cursor,omitempty"`
 }
 
 func SuccessResponse(c *fiber.Ctx, message string, data interface{}) error {
@@ -70,6 +74,14 @@ func CreatedResponse(c *fiber.Ctx, message string, data interface{}) error {
 	})
 }
 
+func AcceptedResponse(c *fiber.Ctx, message string, data interface{}) error {
+	return c.Status(fiber.StatusAccepted).JSON(Response{
+		Success: true,
+		Message: message,
+		Data:    data,
+	})
+}
+
 func ErrorResponse(c *fiber.Ctx, statusCode int, message string) error {
 	return c.Status(statusCode).JSON(SimpleErrorResponse{
 		Success: false,
@@ -105,3 +117,18 @@ func PaginatedSuccessResponse(c *fiber.Ctx, message string, data interface{}, pa
 		},
 	})
 }
+
+func CursorPaginatedSuccessResponse(c *fiber.Ctx, message string, data interface{}, limit, itemsOnPage int, nextCursor, prevCursor string) error {
+	return c.Status(fiber.StatusOK).JSON(PaginatedResponse{
+		Success: true,
+		Message: message,
+		Data:    data,
+		Meta: Meta{
+			ItemsOnPage:  itemsOnPage,
+			PerPage:      limit,
+			HasMorePages: nextCursor != "",
+			NextCursor:   nextCursor,
+			PrevCursor:   prevCursor,
+		},
+	})
+}